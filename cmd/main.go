@@ -2,30 +2,221 @@ package main
 
 import (
     "bufio"
-    "log"
+    "context"
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "log/slog"
+    "net"
+    "net/http"
     "net/url"
     "os"
+    "os/signal"
+    "path/filepath"
     "strings"
+    "syscall"
     "time"
 
     "github.com/go-rod/rod"
     "github.com/go-rod/rod/lib/launcher"
     "github.com/go-rod/rod/lib/proto"
 
+    "github.com/sushmitaRN/linkedin-automation-poc/internal/approval"
     "github.com/sushmitaRN/linkedin-automation-poc/internal/auth"
+    "github.com/sushmitaRN/linkedin-automation-poc/internal/campaign"
     "github.com/sushmitaRN/linkedin-automation-poc/internal/connect"
+    "github.com/sushmitaRN/linkedin-automation-poc/internal/dashboard"
+    "github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+    "github.com/sushmitaRN/linkedin-automation-poc/internal/dnc"
+    "github.com/sushmitaRN/linkedin-automation-poc/internal/dryrun"
+    "github.com/sushmitaRN/linkedin-automation-poc/internal/events"
+    "github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
     "github.com/sushmitaRN/linkedin-automation-poc/internal/message"
     "github.com/sushmitaRN/linkedin-automation-poc/internal/post"
+    "github.com/sushmitaRN/linkedin-automation-poc/internal/queue"
+    "github.com/sushmitaRN/linkedin-automation-poc/internal/ratelimit"
+    "github.com/sushmitaRN/linkedin-automation-poc/internal/scheduler"
     "github.com/sushmitaRN/linkedin-automation-poc/internal/search"
+    "github.com/sushmitaRN/linkedin-automation-poc/internal/templates"
+    "github.com/sushmitaRN/linkedin-automation-poc/internal/webguard"
 )
 
 var searchPageURL = "file:///e:/visualstudio/linkedin-automation-poc/mock-site/search.html"
 
-func main() {
-    log.Println("Starting LinkedIn automation (Rod)")
+// runMode holds the global --dry-run and --sandbox flags
+type runMode struct {
+    // DryRun does everything except the final click or send
+    DryRun bool
+    // Sandbox keeps state and quotas in data/sandbox
+    Sandbox bool
+}
+
+// Production reports whether real actions count against the real quotas
+func (m runMode) Production() bool { return !m.DryRun && !m.Sandbox }
+
+// mode is set once in main, before any flow runs
+var mode runMode
+
+// parseRunMode removes --dry-run and --sandbox from args, wherever they appear
+func parseRunMode(args []string) (runMode, []string) {
+    var m runMode
+    rest := []string{}
+    for _, a := range args {
+        switch strings.TrimLeft(a, "-") {
+        case "dry-run":
+            m.DryRun = true
+        case "sandbox":
+            m.Sandbox = true
+        default:
+            rest = append(rest, a)
+        }
+    }
+    return m, rest
+}
+
+// logOptions holds the global --log-level, --log-format and --log-file flags
+type logOptions struct {
+    Level  string
+    Format string
+    // File receives the log instead of stderr; it is appended to
+    File string
+}
+
+// parseLogOptions removes the logging flags from args, in either the
+// --flag value or --flag=value form. LOG_LEVEL and LOG_FORMAT set the defaults.
+func parseLogOptions(args []string) (logOptions, []string, error) {
+    o := logOptions{Level: os.Getenv("LOG_LEVEL"), Format: os.Getenv("LOG_FORMAT")}
+    fields := map[string]*string{"log-level": &o.Level, "log-format": &o.Format, "log-file": &o.File}
+    rest := []string{}
+    for i := 0; i < len(args); i++ {
+        name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+        dst, ok := fields[name]
+        if !ok || !strings.HasPrefix(args[i], "-") {
+            rest = append(rest, args[i])
+            continue
+        }
+        if !hasValue {
+            if i+1 >= len(args) {
+                return o, nil, fmt.Errorf("--%s needs a value", name)
+            }
+            i++
+            value = args[i]
+        }
+        *dst = value
+    }
+    return o, rest, nil
+}
+
+// newLogger builds the logger described by o
+func newLogger(o logOptions) (*slog.Logger, error) {
+    level, err := logging.ParseLevel(o.Level)
+    if err != nil {
+        return nil, err
+    }
+    var w io.Writer = os.Stderr
+    if o.File != "" {
+        f, err := os.OpenFile(o.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+        if err != nil {
+            return nil, err
+        }
+        w = f
+    }
+    return logging.New(w, o.Format, level)
+}
+
+// logger is set once in main and passed to every package config
+var logger = slog.Default()
+
+// fatalf logs an error and exits
+func fatalf(format string, args ...any) {
+    logger.Error(fmt.Sprintf(format, args...))
+    os.Exit(1)
+}
 
+func main() {
     loadDotEnv()
 
+    logOpts, args, err := parseLogOptions(os.Args[1:])
+    if err == nil {
+        logger, err = newLogger(logOpts)
+    }
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(2)
+    }
+    slog.SetDefault(logger)
+
+    logger.Info("starting LinkedIn automation (Rod)")
+
+    // global flags: --dry-run | --sandbox | --log-level debug|info|warn|error |
+    // --log-format text|json | --log-file path
+    // command: run (default) | withdraw | daemon [--interval d | --cron expr] [--workers n] |
+    // report | deadletters | requeue [profile_url] |
+    // quota status | quota reset --action a [--scope s] [--reason r] |
+    // dnc list | dnc add --kind k --value v [--reason r] | dnc remove --kind k --value v | dnc import file.csv |
+    // approvals list [--all] | approvals approve|edit|reject <id> [--text t] [--reason r] | approvals serve [--addr a] [--token t] |
+    // dashboard [--addr a] [--token t] | api [--addr a] [--token t] | events [--type t] [--since d] [--limit n]
+    mode, args = parseRunMode(args)
+    if mode.Sandbox {
+        datadir.Dir = filepath.Join("data", "sandbox")
+        logger.Info("sandbox mode: state and quotas kept apart", "dir", datadir.Dir)
+    }
+    if mode.DryRun {
+        logger.Info("dry-run mode: no final clicks or sends", "log", dryrun.Path())
+    }
+    if os.Getenv("DEV_IGNORE_QUOTAS") != "" {
+        if mode.Production() {
+            fatalf("DEV_IGNORE_QUOTAS is set: quota bypass is not supported; unset it, or use --dry-run or --sandbox for testing")
+        }
+        logger.Warn("DEV_IGNORE_QUOTAS is ignored; quotas still apply")
+    }
+
+    command := "run"
+    if len(args) > 0 {
+        command = args[0]
+        args = args[1:]
+    }
+    var daemonOpts daemonOptions
+    switch command {
+    case "run", "withdraw":
+    case "daemon":
+        daemonOpts = parseDaemonOptions(args)
+    case "report":
+        printReport()
+        return
+    case "deadletters":
+        listDeadLetters()
+        return
+    case "requeue":
+        requeueDeadLetters(args)
+        return
+    case "quota":
+        runQuota(args)
+        return
+    case "dnc":
+        runDNC(args)
+        return
+    case "approvals":
+        runApprovals(args)
+        return
+    case "dashboard":
+        runDashboard(args)
+        return
+    case "api":
+        runAPI(args)
+        return
+    case "events":
+        printEvents(args)
+        return
+    default:
+        fatalf("unknown command %q (expected run, withdraw, daemon, report, deadletters, requeue, quota, dnc, approvals, dashboard, api or events)", command)
+    }
+
+    // SIGINT/SIGTERM cancel ctx; every flow stops at its next page operation
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
     email := os.Getenv("MOCK_EMAIL")
     password := os.Getenv("MOCK_PASSWORD")
 
@@ -48,32 +239,299 @@ func main() {
     page.MustWaitLoad()
 
     // 2️⃣ Login (this already redirects to search.html)
-    if err := auth.Login(page, email, password); err != nil {
-        log.Fatalf("Login failed: %v", err)
+    if err := auth.Login(ctx, page, email, password); err != nil {
+        var cpErr *auth.CheckpointError
+        if errors.As(err, &cpErr) {
+            fatalf("Login blocked by a %s checkpoint; complete it in the browser and run again: %v", cpErr.Kind, err)
+        }
+        fatalf("Login failed: %v", err)
     }
 
     // 3️⃣ Wait until search page is ready
     cfg := search.DefaultSearchConfig()
+    cfg.Logger = logger
     page.MustWaitLoad()
     page.MustElement(cfg.SearchInputID).MustWaitVisible()
 
-    log.Println("✓ Logged in and search page ready")
+    logger.Info("logged in and search page ready")
 
-    // Reset daily quotas to avoid rate limits during testing
-    _ = os.Setenv("DEV_IGNORE_QUOTAS", "1")
+    // Bring legacy pending entries ("enqueued_at") onto the current schema
+    if err := connect.MigratePending(datadir.Path("pending_messages.json")); err != nil {
+        logger.Warn("could not migrate pending messages", "error", err)
+    }
 
-    connCfg := connect.ConnectConfig{
-        DailyLimit:  5,
-        StoragePath: "data/sent_requests.json",
+    camp := loadCampaign(os.Getenv("CAMPAIGN"))
+
+    switch command {
+    case "withdraw":
+        runWithdraw(ctx, page, camp)
+    case "daemon":
+        runDaemon(ctx, page, cfg, camp, daemonOpts)
+    default:
+        // 4️⃣ Run the required flows
+        runCampaign(ctx, page, cfg, camp, flowOptions{DirectMessage: true, EngagePosts: true})
+        if ctx.Err() != nil {
+            logger.Info("automation interrupted")
+            return
+        }
+        logger.Info("automation complete")
     }
+}
+
+// ---------------- CAMPAIGN FLOW ----------------
+
+// flowOptions selects the optional parts of runSearchFlow
+type flowOptions struct {
+    // DirectMessage sends a message right after connecting instead of
+    // waiting for the queued follow-up
+    DirectMessage bool
+    EngagePosts   bool
+    // MessageLimits are the campaign's extra limits for direct messages
+    MessageLimits []ratelimit.Limit
+}
+
+// defaultSearches are used when the campaign does not declare any
+var defaultSearches = []campaign.Search{
+    {Query: "Bob", Type: "name"},
+    {Query: "VisionaryAI", Type: "company"},
+    {Query: "San Francisco", Type: "location"},
+    {Query: "Engineer", Type: "position"},
+}
+
+func connectConfig(camp campaign.Campaign) connect.ConnectConfig {
+    cfg := connect.ConnectConfig{
+        DailyLimit:      camp.DailyLimit,
+        Limits:          camp.Limits["connect"],
+        Account:         os.Getenv("MOCK_EMAIL"),
+        DryRun:          mode.DryRun,
+        StoragePath:     datadir.Path("sent_requests.json"),
+        Note:            camp.Note,
+        NoteLimit:       camp.NoteLimit,
+        CampaignID:      camp.ID,
+        PendingPath:     datadir.Path("pending_messages.json"),
+        RequireApproval: camp.RequireApproval,
+        Logger:          logger,
+    }
+    // the first message of the sequence is queued when the request is confirmed
+    if i, step, ok := camp.NextMessage(0); ok {
+        cfg.FollowUpTemplateID = step.TemplateID
+        cfg.FollowUpStep = i
+        cfg.FollowUpDelay = time.Duration(step.Delay)
+    }
+    return cfg
+}
+
+// runCampaign runs the search flow for each of the campaign's searches.
+// Nothing runs while the campaign is paused or outside its send window.
+func runCampaign(ctx context.Context, page *rod.Page, cfg search.SearchConfig, camp campaign.Campaign, opts flowOptions) {
+    if campaignPaused(camp.ID) || !campaignWindowOpen(camp, time.Now()) {
+        return
+    }
+    searches := camp.Searches
+    if len(searches) == 0 {
+        searches = defaultSearches
+    }
+    connCfg := connectConfig(camp)
+    opts.MessageLimits = camp.Limits["message"]
+    if camp.RequireApproval && opts.DirectMessage {
+        // unreviewed text must not go out: messages wait in the queue
+        logger.Info("campaign requires approval: direct messages disabled", logging.KeyCampaign, camp.ID)
+        opts.DirectMessage = false
+    }
+    for _, sr := range searches {
+        if ctx.Err() != nil {
+            return
+        }
+        if err := runSearchFlow(ctx, page, cfg, connCfg, sr.Query, sr.Type, opts); err != nil {
+            if errors.Is(err, ratelimit.ErrQuotaExceeded) {
+                logger.Warn("stopping campaign", logging.KeyCampaign, camp.ID, "error", err)
+            }
+            return
+        }
+    }
+}
 
-    // 4️⃣ Run the required flows
-    runSearchFlow(page, cfg, connCfg, "Bob", "name")
-    runSearchFlow(page, cfg, connCfg, "VisionaryAI", "company")
-    runSearchFlow(page, cfg, connCfg, "San Francisco", "location")
-    runSearchFlow(page, cfg, connCfg, "Engineer", "position")
+// ---------------- DAEMON ----------------
 
-    log.Println("✓ Automation complete")
+// daemonOptions are the daemon command's flags
+type daemonOptions struct {
+    Schedule scheduler.Schedule
+    // Workers lets jobs of different types run on separate pages
+    Workers int
+    // StepTimeout bounds each queued job; zero means no limit
+    StepTimeout time.Duration
+}
+
+// parseDaemonOptions reads --interval (default 15m) or --cron, --workers and --step-timeout from args
+func parseDaemonOptions(args []string) daemonOptions {
+    fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+    interval := fs.Duration("interval", 15*time.Minute, "time between daemon cycles")
+    cronExpr := fs.String("cron", "", "5-field cron expression; overrides --interval")
+    workers := fs.Int("workers", 1, "jobs run at once (each on its own page, at most one per job type)")
+    stepTimeout := fs.Duration("step-timeout", 5*time.Minute, "maximum time for one job (0 = no limit)")
+    _ = fs.Parse(args)
+
+    opts := daemonOptions{Workers: *workers, StepTimeout: *stepTimeout}
+    if *cronExpr != "" {
+        c, err := scheduler.ParseCron(*cronExpr)
+        if err != nil {
+            fatalf("invalid --cron: %v", err)
+        }
+        logger.Info("daemon schedule", "cron", *cronExpr)
+        opts.Schedule = c
+        return opts
+    }
+    logger.Info("daemon schedule", "every", *interval)
+    opts.Schedule = scheduler.Every(*interval)
+    return opts
+}
+
+// runDaemon keeps the browser session and, on every wake-up, refreshes
+// connection statuses and then runs one priority queue holding due
+// pending messages, stale-request withdrawals, campaign searches (connects)
+// and post engagement. Cancelling ctx interrupts the running jobs; queues
+// are still saved before it returns.
+func runDaemon(ctx context.Context, page *rod.Page, cfg search.SearchConfig, camp campaign.Campaign, opts daemonOptions) {
+    err := scheduler.RunDaemon(ctx, opts.Schedule, func(ctx context.Context) error {
+        if n, err := connect.RefreshStatuses(ctx, page, datadir.Path("sent_requests.json"), logger); err != nil {
+            logger.Warn("connection status check failed", "error", err)
+        } else if n > 0 {
+            logger.Info("connections newly accepted", "count", n)
+        }
+        if ctx.Err() != nil {
+            return nil
+        }
+
+        now := time.Now()
+        q := queue.New(now)
+
+        batch, msgJobs, err := scheduler.PrepareMessages(scheduler.SchedulerConfig{Account: os.Getenv("MOCK_EMAIL"), DryRun: mode.DryRun, Logger: logger}, now)
+        if err != nil {
+            return err
+        }
+        q.Push(msgJobs...)
+        q.Push(withdrawJobs(camp, now)...)
+        q.Push(campaignJobs(cfg, camp, now)...)
+
+        d := queue.Dispatcher{
+            Page:       page,
+            MaxWorkers: opts.Workers,
+            NewPage: func() (*rod.Page, error) {
+                return page.Browser().Page(proto.TargetCreateTarget{})
+            },
+            Quotas:     map[queue.JobType]int{queue.JobEngage: 1},
+            JobTimeout: opts.StepTimeout,
+            HaltType:   scheduler.HaltOnQuota,
+            Logger:     logger,
+        }
+        res := d.Run(ctx, q)
+        logger.Info("daemon jobs finished", "done", res.Done, "failed", res.Failed, "deferred", len(res.Deferred))
+
+        return batch.Save()
+    }, logger)
+    if err != nil {
+        fatalf("daemon failed: %v", err)
+    }
+    logger.Info("daemon stopped")
+}
+
+// withdrawJobs returns a withdraw job for every stale connection request,
+// none while the campaign is paused
+func withdrawJobs(camp campaign.Campaign, now time.Time) []queue.Job {
+    if campaignPaused(camp.ID) {
+        return nil
+    }
+    wCfg := withdrawConfig(camp)
+    stale, err := connect.StaleRequests(wCfg.StoragePath, wCfg.MaxAge, now)
+    if err != nil {
+        logger.Warn("could not load stale requests", "error", err)
+        return nil
+    }
+
+    jobs := make([]queue.Job, 0, len(stale))
+    for _, r := range stale {
+        profileURL := r.ProfileURL
+        jobs = append(jobs, queue.Job{
+            Type:       queue.JobWithdraw,
+            ProfileURL: profileURL,
+            EnqueuedAt: r.Timestamp,
+            Run: func(ctx context.Context, p *rod.Page) error {
+                _, err := connect.WithdrawRequest(ctx, p, wCfg, profileURL)
+                return err
+            },
+        })
+    }
+    return jobs
+}
+
+// campaignJobs returns a connect job per campaign search and one engage job.
+// Nothing is returned while the campaign is paused or outside its send
+// window (evaluated in the campaign/operator timezone).
+func campaignJobs(cfg search.SearchConfig, camp campaign.Campaign, now time.Time) []queue.Job {
+    if campaignPaused(camp.ID) || !campaignWindowOpen(camp, now) {
+        return nil
+    }
+
+    searches := camp.Searches
+    if len(searches) == 0 {
+        searches = defaultSearches
+    }
+    connCfg := connectConfig(camp)
+
+    jobs := []queue.Job{}
+    for _, sr := range searches {
+        sr := sr
+        jobs = append(jobs, queue.Job{
+            Type:       queue.JobConnect,
+            CampaignID: camp.ID,
+            EnqueuedAt: now,
+            Run: func(ctx context.Context, p *rod.Page) error {
+                return runSearchFlow(ctx, p, cfg, connCfg, sr.Query, sr.Type, flowOptions{})
+            },
+        })
+    }
+    jobs = append(jobs, queue.Job{
+        Type:       queue.JobEngage,
+        CampaignID: camp.ID,
+        EnqueuedAt: now,
+        Run: func(ctx context.Context, p *rod.Page) error {
+            p = p.Context(ctx)
+            if err := p.Navigate(searchPageURL); err != nil {
+                return err
+            }
+            if err := p.WaitLoad(); err != nil {
+                return err
+            }
+            return post.InteractWithPosts(ctx, p, 1, post.Config{DryRun: mode.DryRun, Logger: logger})
+        },
+    })
+    return jobs
+}
+
+// ---------------- WITHDRAW ----------------
+
+// runWithdraw withdraws connection requests that stayed pending longer than
+// the campaign's withdraw_after_days.
+func runWithdraw(ctx context.Context, page *rod.Page, camp campaign.Campaign) {
+    n, err := connect.WithdrawStale(ctx, page, withdrawConfig(camp))
+    if err != nil {
+        fatalf("withdraw failed: %v", err)
+    }
+    logger.Info("stale connection requests withdrawn", "count", n)
+}
+
+func withdrawConfig(camp campaign.Campaign) connect.WithdrawConfig {
+    wCfg := connect.WithdrawConfig{
+        MaxAge:      connect.DefaultWithdrawAfter,
+        StoragePath: datadir.Path("sent_requests.json"),
+        PendingPath: datadir.Path("pending_messages.json"),
+        DryRun:      mode.DryRun,
+        Logger:      logger,
+    }
+    if camp.WithdrawAfterDays > 0 {
+        wCfg.MaxAge = time.Duration(camp.WithdrawAfterDays) * 24 * time.Hour
+    }
+    return wCfg
 }
 
 // ---------------- SEARCH ----------------
@@ -82,7 +540,7 @@ func main() {
 func runSearch(page *rod.Page, query string) {
     cfg := search.DefaultSearchConfig()
 
-    log.Printf("Searching for: %s", query)
+    logger.Info("searching", "query", query)
 
     input := page.MustElement(cfg.SearchInputID)
     input.MustSelectAllText()
@@ -92,7 +550,7 @@ func runSearch(page *rod.Page, query string) {
 
     page.MustWaitElementsMoreThan(cfg.ProfileLinkSel, 0)
     results := page.MustElements(cfg.ProfileLinkSel)
-    log.Printf("✓ Found %d results for %q", len(results), query)
+    logger.Info("search results", "query", query, "count", len(results))
 }
 
 // choose radio button for search type on search.html
@@ -108,13 +566,13 @@ func setSearchType(page *rod.Page, searchType string) {
     }
     el, err := page.Element(sel)
     if err != nil || el == nil {
-        log.Printf("warning: could not select search type %s (%s)", searchType, sel)
+        logger.Warn("could not select search type", "type", searchType, "selector", sel)
         return
     }
     if err := el.Click(proto.InputMouseButtonLeft, 1); err != nil {
-        log.Printf("warning: click failed for search type %s: %v", searchType, err)
+        logger.Warn("click failed for search type", "type", searchType, "error", err)
     } else {
-        log.Printf("✓ Search type set to %s", searchType)
+        logger.Debug("search type set", "type", searchType)
     }
 }
 
@@ -131,14 +589,17 @@ func normalize(href string) string {
     return "file:///e:/visualstudio/linkedin-automation-poc/mock-site/" + href
 }
 
-// runSearchFlow: search → open first profile → connect → message (except companies) → post interaction
-func runSearchFlow(page *rod.Page, cfg search.SearchConfig, connCfg connect.ConnectConfig, query, searchType string) {
-    log.Printf("Searching & processing: %s (type=%s)", query, searchType)
+// runSearchFlow: search → open first profile → connect → message (except companies) → post interaction.
+// Problems with one search are logged; the returned error is non-nil only when
+// the rest of the campaign should stop too (cancellation or a used-up quota).
+func runSearchFlow(ctx context.Context, page *rod.Page, cfg search.SearchConfig, connCfg connect.ConnectConfig, query, searchType string, opts flowOptions) error {
+    lg := logger.With(logging.KeyCampaign, connCfg.CampaignID, "query", query, "type", searchType)
+    lg.Info("searching and processing")
 
     // ensure search page
     if err := page.Navigate(searchPageURL); err != nil {
-        log.Printf("warning: could not navigate to search page for %q: %v", query, err)
-        return
+        lg.Warn("could not navigate to search page", "error", err)
+        return nil
     }
     page.MustWaitLoad()
 
@@ -146,14 +607,14 @@ func runSearchFlow(page *rod.Page, cfg search.SearchConfig, connCfg connect.Conn
     setSearchType(page, searchType)
 
     // run search
-    elems, err := search.Search(page, query, cfg)
+    elems, err := search.Search(ctx, page, query, cfg)
     if err != nil {
-        log.Printf("warning: search failed for %q: %v", query, err)
-        return
+        lg.Warn("search failed", "error", err)
+        return nil
     }
     if len(elems) == 0 {
-        log.Printf("no profiles found for %q", query)
-        return
+        lg.Info("no profiles found")
+        return nil
     }
 
     // log top results
@@ -163,43 +624,49 @@ func runSearchFlow(page *rod.Page, cfg search.SearchConfig, connCfg connect.Conn
     }
     for i := 0; i < maxShow; i++ {
         t, _ := elems[i].Text()
-        log.Printf("  result %d: %s", i+1, strings.TrimSpace(t))
+        lg.Debug("search result", "n", i+1, "text", strings.TrimSpace(t))
     }
 
     firstEl := elems[0]
     href := search.ExtractProfileURL(firstEl)
     profURL := normalize(href)
     if profURL == "" {
-        log.Printf("no URL for first profile of %q, skipping", query)
-        return
+        lg.Info("no URL for first profile, skipping")
+        return nil
     }
 
     nameText, _ := firstEl.Text()
     nameText = strings.TrimSpace(nameText)
-    log.Printf("Opening profile: %s (%s) [type=%s]", nameText, profURL, searchType)
+    lg.Info("opening profile", "name", nameText, logging.KeyProspect, profURL)
 
     if searchType == "company" {
         // company search: go to company.html and only send connect
         q := url.QueryEscape(query)
         compURL := normalize("company.html?id=" + q)
         if err := page.Navigate(compURL); err != nil {
-            log.Printf("could not navigate to company profile %s: %v", compURL, err)
-            return
+            lg.Warn("could not navigate to company profile", logging.KeyProspect, compURL, "error", err)
+            return nil
         }
         page.MustWaitLoad()
         if el, err := page.Element("#company-name"); err != nil || el == nil {
-            log.Printf("warning: company page may not have loaded correctly for %s", query)
+            lg.Warn("company page may not have loaded correctly", logging.KeyProspect, compURL)
         }
 
-        // connect (if your connect logic supports company pages)
-        if err := connect.Connect(page, compURL, connCfg); err != nil {
-            log.Printf("warning: connect request failed for company %s: %v", compURL, err)
+        // connect (company pages follow without an invitation note)
+        compCfg := connCfg
+        compCfg.Note = ""
+        compCfg.FollowUpTemplateID = ""
+        if outcome, err := connect.Connect(ctx, page, compURL, nil, compCfg); err != nil {
+            lg.Warn("connect request failed", logging.KeyProspect, compURL, "outcome", outcome, "error", err)
+            if errors.Is(err, ratelimit.ErrQuotaExceeded) {
+                return err
+            }
         } else {
-            log.Printf("✓ Connect request sent to company %s", query)
+            lg.Info("connect finished", logging.KeyProspect, compURL, "outcome", outcome)
         }
 
         // skip direct messaging for companies
-        return
+        return ctx.Err()
     }
 
     // non-company: open person profile
@@ -208,10 +675,10 @@ func runSearchFlow(page *rod.Page, cfg search.SearchConfig, connCfg connect.Conn
     time.Sleep(250 * time.Millisecond)
 
     if err := firstEl.Click(proto.InputMouseButtonLeft, 1); err != nil {
-        log.Printf("click failed for first result, navigating to %s: %v", profURL, err)
+        lg.Debug("click failed for first result, navigating to profile", logging.KeyProspect, profURL, "error", err)
         if err := page.Navigate(profURL); err != nil {
-            log.Printf("could not navigate to profile %s: %v", profURL, err)
-            return
+            lg.Warn("could not navigate to profile", logging.KeyProspect, profURL, "error", err)
+            return nil
         }
     }
 
@@ -219,18 +686,10 @@ func runSearchFlow(page *rod.Page, cfg search.SearchConfig, connCfg connect.Conn
     time.Sleep(600 * time.Millisecond)
 
     if el, err := page.Element("#name"); err != nil || el == nil {
-        log.Printf("warning: profile page may not have loaded correctly for %s", nameText)
+        lg.Warn("profile page may not have loaded correctly", logging.KeyProspect, profURL)
     }
 
-    // connect
-    if err := connect.Connect(page, profURL, connCfg); err != nil {
-        log.Printf("warning: connect request failed for %s: %v", profURL, err)
-    } else {
-        log.Printf("✓ Connect request sent to %s", nameText)
-    }
-
-    // build message template
-    tmpl := "Hi {{first_name}}, thanks for connecting — are there any openings at {{company}}?"
+    // template vars shared by the connect note and the message
     firstNameParts := strings.Split(nameText, " ")
     fn := ""
     if len(firstNameParts) > 0 {
@@ -243,27 +702,580 @@ func runSearchFlow(page *rod.Page, cfg search.SearchConfig, connCfg connect.Conn
             vars["company"] = strings.TrimSpace(txt)
         }
     }
+    if el, err := page.Element("#location"); err == nil && el != nil {
+        if txt, err := el.Text(); err == nil && strings.TrimSpace(txt) != "" {
+            vars["location"] = strings.TrimSpace(txt)
+            if tz := campaign.TimezoneForLocation(vars["location"]); tz != "" {
+                vars["timezone"] = tz
+            }
+        }
+    }
 
-    if err := message.SendMessage(page, profURL, tmpl, vars, message.MessageConfig{StoragePath: "data/sent_messages.json"}); err != nil {
-        log.Printf("warning: sending message to %s failed: %v", profURL, err)
-    } else {
-        log.Printf("✓ Message sent to %s", nameText)
-    }
-
-    // interact with posts (1 per profile)
-    log.Printf("Interacting with post for: %s", nameText)
-    postsPage := page.Browser().MustPage(searchPageURL)
-    if postsPage != nil {
-        postsPage.MustWaitLoad()
-        time.Sleep(500 * time.Millisecond)
-        _ = post.InteractWithPosts(postsPage, 1)
-        post.HumanScroll(postsPage, 300)
-        _ = postsPage.Close()
+    // connect
+    if outcome, err := connect.Connect(ctx, page, profURL, vars, connCfg); err != nil {
+        if errors.Is(err, dnc.ErrBlocked) {
+            // opted out: no message or engagement either
+            return ctx.Err()
+        }
+        lg.Warn("connect request failed", logging.KeyProspect, profURL, "outcome", outcome, "error", err)
+        if errors.Is(err, ratelimit.ErrQuotaExceeded) {
+            return err
+        }
     } else {
-        log.Printf("warning: could not open posts page after processing %s", nameText)
+        lg.Info("connect finished", logging.KeyProspect, profURL, "outcome", outcome)
+    }
+
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+
+    if opts.DirectMessage {
+        // build message template
+        tmpl := "Hi {{first_name}}, thanks for connecting — are there any openings at {{company}}?"
+
+        msgCfg := message.MessageConfig{
+            StoragePath: datadir.Path("sent_messages.json"),
+            Account:     connCfg.Account,
+            CampaignID:  connCfg.CampaignID,
+            Limits:      opts.MessageLimits,
+            DryRun:      mode.DryRun,
+            Logger:      logger,
+        }
+        if err := message.SendMessage(ctx, page, profURL, tmpl, vars, msgCfg); err != nil {
+            lg.Warn("sending message failed", logging.KeyProspect, profURL, "error", err)
+        } else {
+            lg.Info("message sent", logging.KeyProspect, profURL)
+        }
+    }
+
+    if opts.EngagePosts && ctx.Err() == nil {
+        // interact with posts (1 per profile)
+        lg.Debug("interacting with posts", logging.KeyProspect, profURL)
+        postsPage := page.Browser().MustPage(searchPageURL)
+        if postsPage != nil {
+            postsPage.MustWaitLoad()
+            time.Sleep(500 * time.Millisecond)
+            _ = post.InteractWithPosts(ctx, postsPage, 1, post.Config{DryRun: mode.DryRun, Logger: logger})
+            post.HumanScroll(ctx, postsPage, 300)
+            _ = postsPage.Close()
+        } else {
+            lg.Warn("could not open posts page", logging.KeyProspect, profURL)
+        }
     }
 
     time.Sleep(800 * time.Millisecond)
+    return ctx.Err()
+}
+
+// ---------------- REPORT ----------------
+
+// printReport summarizes sent requests and lists the pending queue with
+// the time each message is planned to go out.
+func printReport() {
+    pending, err := connect.PendingRequests(datadir.Path("sent_requests.json"))
+    if err != nil {
+        fatalf("could not load sent requests: %v", err)
+    }
+    fmt.Printf("Connection requests awaiting acceptance: %d\n", len(pending))
+
+    plan, err := scheduler.Plan(scheduler.SchedulerConfig{}, time.Now())
+    if err != nil {
+        fatalf("could not load pending messages: %v", err)
+    }
+    fmt.Printf("\nPending messages: %d\n", len(plan))
+    for i, p := range plan {
+        pm := p.Message
+        planned := p.PlannedAt.Format("Mon 2006-01-02 15:04 MST")
+        if p.WindowErr != nil {
+            planned += " (send window error: " + p.WindowErr.Error() + ")"
+        }
+        campaignID := pm.CampaignID
+        if campaignID == "" {
+            campaignID = "-"
+        }
+        fmt.Printf("%d. %s  template=%s  campaign=%s  attempts=%d\n   planned send: %s\n",
+            i+1, pm.ProfileURL, pm.TemplateID, campaignID, pm.Attempts, planned)
+    }
+
+    dead, err := scheduler.LoadDeadLetters("")
+    if err == nil && len(dead) > 0 {
+        fmt.Printf("\nDead letters: %d (see `deadletters`)\n", len(dead))
+    }
+}
+
+// printEvents prints the latest entries of the action event log
+func printEvents(args []string) {
+    fs := flag.NewFlagSet("events", flag.ExitOnError)
+    typ := fs.String("type", "", "only this action type (connect, message, withdraw, ...)")
+    since := fs.Duration("since", 0, "only events from this long ago, e.g. 24h")
+    limit := fs.Int("limit", 50, "show at most this many of the latest events")
+    _ = fs.Parse(args)
+
+    all, err := events.Load("")
+    if err != nil {
+        fatalf("could not load event log: %v", err)
+    }
+    var shown []events.Event
+    for _, e := range all {
+        if *typ != "" && string(e.Type) != *typ {
+            continue
+        }
+        if *since > 0 && e.Time.Before(time.Now().Add(-*since)) {
+            continue
+        }
+        shown = append(shown, e)
+    }
+    if *limit > 0 && len(shown) > *limit {
+        shown = shown[len(shown)-*limit:]
+    }
+    if len(shown) == 0 {
+        fmt.Println("No events.")
+        return
+    }
+    for _, e := range shown {
+        target := e.ProfileURL
+        if target == "" {
+            target = e.Detail
+        }
+        fmt.Printf("%s  %-12s %-18s %6dms  %s", e.Time.Format(time.RFC3339), e.Type, e.Outcome, e.DurationMS, target)
+        if e.CampaignID != "" {
+            fmt.Printf("  campaign=%s", e.CampaignID)
+        }
+        if e.Error != "" {
+            fmt.Printf("\n    error: %s", e.Error)
+        }
+        fmt.Println()
+    }
+}
+
+// ---------------- DEAD LETTERS ----------------
+
+// listDeadLetters prints messages that exhausted their retry attempts
+func listDeadLetters() {
+    dead, err := scheduler.LoadDeadLetters("")
+    if err != nil {
+        fatalf("could not load dead letters: %v", err)
+    }
+    if len(dead) == 0 {
+        fmt.Println("No dead letters.")
+        return
+    }
+    for i, d := range dead {
+        fmt.Printf("%d. %s  template=%s  attempts=%d  failed_at=%s\n   last error: %s\n",
+            i+1, d.Message.ProfileURL, d.Message.TemplateID, d.Message.Attempts,
+            d.FailedAt.Format(time.RFC3339), d.Message.LastError)
+    }
+}
+
+// requeueDeadLetters moves dead letters back to the pending queue.
+// With no argument every dead letter is requeued.
+func requeueDeadLetters(args []string) {
+    profileURL := ""
+    if len(args) > 0 {
+        profileURL = args[0]
+    }
+    n, err := scheduler.Requeue(scheduler.SchedulerConfig{}, profileURL)
+    if err != nil {
+        fatalf("requeue failed: %v", err)
+    }
+    logger.Info("dead letters requeued", "count", n)
+}
+
+// ---------------- QUOTAS ----------------
+
+// runQuota handles `quota status` and `quota reset`
+func runQuota(args []string) {
+    sub := "status"
+    if len(args) > 0 {
+        sub, args = args[0], args[1:]
+    }
+    switch sub {
+    case "status":
+        printQuotaStatus()
+    case "reset":
+        resetQuota(args)
+    default:
+        fatalf("unknown quota command %q (expected status or reset)", sub)
+    }
+}
+
+// quotaChains returns every configured limit per action: global and
+// account limits, each campaign's connect and message limits and each
+// template's daily limit
+func quotaChains() map[string][]ratelimit.ScopeLimits {
+    chains := map[string][]ratelimit.ScopeLimits{}
+    seen := map[string]bool{}
+    add := func(action string, chain []ratelimit.ScopeLimits) {
+        for _, sl := range chain {
+            k := action + "@" + string(sl.Scope)
+            if len(sl.Limits) == 0 || seen[k] {
+                continue
+            }
+            seen[k] = true
+            chains[action] = append(chains[action], sl)
+        }
+    }
+
+    account := os.Getenv("MOCK_EMAIL")
+    camps, err := campaign.LoadCampaigns("")
+    if err != nil {
+        logger.Warn("could not load campaigns", "error", err)
+    }
+    if len(camps) == 0 {
+        camps = []campaign.Campaign{loadCampaign("")}
+    }
+    for _, c := range camps {
+        cc := connectConfig(c)
+        if cc.DailyLimit <= 0 {
+            cc.DailyLimit = 5
+        }
+        chain, err := cc.QuotaChain()
+        if err != nil {
+            fatalf("could not load limits: %v", err)
+        }
+        add("connect", chain)
+
+        chain, err = message.MessageConfig{Account: account, CampaignID: c.ID, Limits: c.Limits["message"]}.QuotaChain()
+        if err != nil {
+            fatalf("could not load limits: %v", err)
+        }
+        add("message", chain)
+    }
+
+    tpls, err := templates.LoadTemplates("")
+    if err != nil {
+        logger.Warn("could not load templates", "error", err)
+    }
+    for _, t := range tpls {
+        if t.DailyLimit > 0 {
+            add("message", []ratelimit.ScopeLimits{{Scope: ratelimit.TemplateScope(t.ID), Limits: []ratelimit.Limit{ratelimit.Daily(t.DailyLimit)}}})
+        }
+    }
+    return chains
+}
+
+// printQuotaStatus lists usage, limit, window and reset time for every
+// action and scope
+func printQuotaStatus() {
+    now := time.Now()
+    usage, err := ratelimit.Status(quotaChains(), datadir.Path("quotas.json"), now)
+    if err != nil {
+        fatalf("could not read quotas: %v", err)
+    }
+    if len(usage) == 0 {
+        fmt.Println("No quotas configured or recorded.")
+        return
+    }
+    const layout = "Mon 2006-01-02 15:04 MST"
+    action := ""
+    for _, u := range usage {
+        if u.Action != action {
+            action = u.Action
+            fmt.Printf("\n%s\n", action)
+        }
+        if u.Limit == nil {
+            fmt.Printf("  %-32s used %d (no limit configured)\n", u.Scope, u.Used)
+            continue
+        }
+        reserved := ""
+        if u.Reserved > 0 {
+            reserved = fmt.Sprintf(" + %d reserved", u.Reserved)
+        }
+        reset := "-"
+        if !u.ResetAt.IsZero() {
+            reset = u.ResetAt.Format(layout)
+        }
+        fmt.Printf("  %-32s %d/%d%s  %s  window from %s  resets %s\n",
+            u.Scope, u.Used, u.Limit.Max, reserved, u.Limit, u.WindowStart.Format(layout), reset)
+    }
+}
+
+// resetQuota clears one action's count in one scope; the change is
+// appended to the quota audit log
+func resetQuota(args []string) {
+    fs := flag.NewFlagSet("quota reset", flag.ExitOnError)
+    action := fs.String("action", "", "action to reset (connect, message, ...)")
+    scope := fs.String("scope", "global", "scope: global, account:ID, campaign:ID or template:ID")
+    reason := fs.String("reason", "", "why the quota is reset (stored in the audit log)")
+    _ = fs.Parse(args)
+    if *action == "" {
+        fatalf("quota reset: --action is required")
+    }
+
+    entry, err := ratelimit.Reset(*action, ratelimit.Scope(*scope), operator(), *reason, datadir.Path("quotas.json"))
+    if err != nil {
+        fatalf("quota reset failed: %v", err)
+    }
+    logger.Info("quota reset", "quota_action", entry.Action, "scope", entry.Scope,
+        "removed", entry.Removed, "released", entry.Released, "audit", ratelimit.AuditPath())
+}
+
+// ---------------- DO NOT CONTACT ----------------
+
+// runDNC lists and edits the do-not-contact list; every change is audited
+func runDNC(args []string) {
+    sub := "list"
+    if len(args) > 0 {
+        sub, args = args[0], args[1:]
+    }
+    by := operator()
+    switch sub {
+    case "list":
+        list, err := dnc.Load("")
+        if err != nil {
+            fatalf("could not load do-not-contact list: %v", err)
+        }
+        if len(list) == 0 {
+            fmt.Println("Do-not-contact list is empty.")
+            return
+        }
+        for i, e := range list {
+            fmt.Printf("%d. %-8s %s  reason=%q  added=%s\n", i+1, e.Kind, e.Value, e.Reason, e.AddedAt.Format(time.RFC3339))
+        }
+    case "add", "remove":
+        fs := flag.NewFlagSet("dnc "+sub, flag.ExitOnError)
+        kind := fs.String("kind", "profile", "profile, company or name")
+        value := fs.String("value", "", "profile id or URL, company name, or name pattern (e.g. \"*wilson\")")
+        reason := fs.String("reason", "", "why the prospect must not be contacted")
+        _ = fs.Parse(args)
+        if *value == "" {
+            fatalf("dnc %s: --value is required", sub)
+        }
+        if sub == "remove" {
+            ok, err := dnc.Remove("", by, dnc.Kind(*kind), *value)
+            if err != nil {
+                fatalf("dnc remove failed: %v", err)
+            }
+            if !ok {
+                fatalf("dnc remove: no %s entry %q", *kind, *value)
+            }
+            logger.Info("removed from the do-not-contact list", "kind", *kind, "value", *value)
+            return
+        }
+        n, err := dnc.Add("", by, dnc.Entry{Kind: dnc.Kind(*kind), Value: *value, Reason: *reason, Source: "cli"})
+        if err != nil {
+            fatalf("dnc add failed: %v", err)
+        }
+        logger.Info("do-not-contact entries added", "count", n)
+    case "import":
+        if len(args) == 0 {
+            fatalf("dnc import: CSV file required (rows: kind,value[,reason])")
+        }
+        f, err := os.Open(args[0])
+        if err != nil {
+            fatalf("dnc import: %v", err)
+        }
+        defer f.Close()
+        n, err := dnc.ImportCSV("", by, filepath.Base(args[0]), f)
+        if err != nil {
+            fatalf("dnc import failed: %v", err)
+        }
+        logger.Info("do-not-contact entries imported", "count", n, "file", args[0])
+    default:
+        fatalf("unknown dnc command %q (expected list, add, remove or import)", sub)
+    }
+}
+
+// ---------------- APPROVALS ----------------
+
+// runApprovals handles "approvals list|approve|edit|reject|serve"
+func runApprovals(args []string) {
+    sub := "list"
+    if len(args) > 0 {
+        sub, args = args[0], args[1:]
+    }
+    switch sub {
+    case "list":
+        fs := flag.NewFlagSet("approvals list", flag.ExitOnError)
+        all := fs.Bool("all", false, "include reviewed and sent items")
+        _ = fs.Parse(args)
+        var statuses []approval.Status
+        if !*all {
+            statuses = []approval.Status{approval.StatusPending}
+        }
+        items, err := approval.List("", statuses...)
+        if err != nil {
+            fatalf("could not load approval queue: %v", err)
+        }
+        if len(items) == 0 {
+            fmt.Println("Nothing to review.")
+            return
+        }
+        for _, it := range items {
+            fmt.Printf("%s  %-8s %-7s %s  campaign=%s  queued=%s\n", it.ID, it.Status, it.Kind, it.ProfileURL, it.CampaignID, it.CreatedAt.Format(time.RFC3339))
+            if it.ReviewedBy != "" {
+                fmt.Printf("    reviewed by %s %s\n", it.ReviewedBy, it.Reason)
+            }
+            fmt.Printf("    %s\n", it.Text)
+        }
+    case "approve", "edit", "reject":
+        if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+            fatalf("approvals %s: item id required", sub)
+        }
+        id := args[0]
+        fs := flag.NewFlagSet("approvals "+sub, flag.ExitOnError)
+        by := fs.String("by", operator(), "reviewer name recorded with the decision")
+        text := fs.String("text", "", "replacement text")
+        reason := fs.String("reason", "", "why the item is rejected")
+        _ = fs.Parse(args[1:])
+        var (
+            it  approval.Item
+            err error
+        )
+        switch sub {
+        case "approve":
+            it, err = approval.Approve("", id, *by, *text)
+        case "edit":
+            if *text == "" {
+                fatalf("approvals edit: --text is required")
+            }
+            it, err = approval.Edit("", id, *by, *text)
+        case "reject":
+            it, err = approval.Reject("", id, *by, *reason)
+        }
+        if err != nil {
+            fatalf("approvals %s failed: %v", sub, err)
+        }
+        logger.Info("approval reviewed", "approval", it.ID, "kind", it.Kind, logging.KeyProspect, it.ProfileURL, "status", it.Status, "by", *by)
+    case "serve":
+        fs := flag.NewFlagSet("approvals serve", flag.ExitOnError)
+        addr := fs.String("addr", approval.DefaultAddr, "listen address")
+        token := fs.String("token", os.Getenv("DASHBOARD_TOKEN"), "token required on every request (default $DASHBOARD_TOKEN)")
+        _ = fs.Parse(args)
+        h := approval.Handler(approval.WebConfig{Guard: webGuard("approval queue", *addr, *token), Logger: logger})
+        logger.Info("approval queue listening", "url", "http://"+*addr+"/")
+        if err := http.ListenAndServe(*addr, h); err != nil {
+            fatalf("approvals serve: %v", err)
+        }
+    default:
+        fatalf("unknown approvals command %q (expected list, approve, edit, reject or serve)", sub)
+    }
+}
+
+// ---------------- DASHBOARD ----------------
+
+// runDashboard serves the local dashboard until interrupted. The optional
+// token comes from --token or DASHBOARD_TOKEN.
+func runDashboard(args []string) {
+    fs := flag.NewFlagSet("dashboard", flag.ExitOnError)
+    addr := fs.String("addr", dashboard.DefaultAddr, "listen address")
+    token := fs.String("token", os.Getenv("DASHBOARD_TOKEN"), "token required on every request (default $DASHBOARD_TOKEN)")
+    _ = fs.Parse(args)
+    h := dashboard.Handler(dashboard.Config{
+        QuotaChains: quotaChains,
+        Operator:    operator(),
+        Guard:       webGuard("dashboard", *addr, *token),
+        Logger:      logger,
+    })
+    logger.Info("dashboard listening", "url", "http://"+*addr+"/")
+    if err := http.ListenAndServe(*addr, h); err != nil {
+        fatalf("dashboard: %v", err)
+    }
+}
+
+// runAPI serves the read-only JSON API until interrupted. The token comes
+// from --token or API_TOKEN.
+func runAPI(args []string) {
+    fs := flag.NewFlagSet("api", flag.ExitOnError)
+    addr := fs.String("addr", dashboard.DefaultAPIAddr, "listen address")
+    token := fs.String("token", os.Getenv("API_TOKEN"), "bearer token required on every request (default $API_TOKEN)")
+    _ = fs.Parse(args)
+    h, err := dashboard.APIHandler(dashboard.Config{QuotaChains: quotaChains, Logger: logger}, *token)
+    if err != nil {
+        fatalf("api: %v (set API_TOKEN in .env or pass --token)", err)
+    }
+    if host, _, err := net.SplitHostPort(*addr); err != nil || !webguard.IsLoopback(host) {
+        logger.Warn("API is reachable from other machines", "addr", *addr)
+    }
+    logger.Info("JSON API listening", "url", "http://"+*addr+"/", "endpoints", "campaigns, prospects, queue, quotas, events")
+    if err := http.ListenAndServe(*addr, h); err != nil {
+        fatalf("api: %v", err)
+    }
+}
+
+// webGuard protects a local web page served on addr, warning when it is
+// reachable from other machines without a token
+func webGuard(name, addr, token string) webguard.Guard {
+    if host, _, err := net.SplitHostPort(addr); (err != nil || !webguard.IsLoopback(host)) && token == "" {
+        logger.Warn(name+" is reachable from other machines without a token; pass --token", "addr", addr)
+    }
+    if token != "" {
+        logger.Info(name + " requires a token: open it once with ?token=<token>")
+    }
+    return webguard.Guard{Addr: addr, Token: token}
+}
+
+// operator names the person running a CLI change, for audit logs
+func operator() string {
+    if u := os.Getenv("USER"); u != "" {
+        return u
+    }
+    return os.Getenv("USERNAME")
+}
+
+// ---------------- CAMPAIGN ----------------
+
+// campaignPaused reports whether the campaign was paused from the dashboard
+func campaignPaused(id string) bool {
+    paused, err := campaign.IsPaused("", id)
+    if err != nil {
+        logger.Warn("could not read campaign state", "error", err)
+        return false
+    }
+    if paused {
+        logger.Info("campaign is paused, skipping", logging.KeyCampaign, id)
+    }
+    return paused
+}
+
+// campaignWindowOpen reports whether now falls inside the campaign's send
+// window (evaluated in the campaign/operator timezone); outside it the
+// next slot is logged. A window that cannot be evaluated does not block.
+func campaignWindowOpen(camp campaign.Campaign, now time.Time) bool {
+    w := camp.SendWindow
+    if w == nil {
+        return true
+    }
+    open, err := w.NextOpen(now, "")
+    if err != nil {
+        logger.Warn("could not compute send window", logging.KeyCampaign, camp.ID, "error", err)
+        return true
+    }
+    if open.After(now) {
+        logger.Info("campaign outside send window", logging.KeyCampaign, camp.ID, "next_slot", open.Format(time.RFC3339))
+        return false
+    }
+    return true
+}
+
+// loadCampaign returns the campaign with the given id from data/campaigns.json,
+// falling back to "default" and then to built-in settings.
+func loadCampaign(id string) campaign.Campaign {
+    if id == "" {
+        id = "default"
+    }
+    fallback := campaign.Campaign{
+        ID:         id,
+        Note:       "Hi {{first_name}}, I came across your work at {{company}} and would love to connect.",
+        NoteLimit:  connect.DefaultNoteLimit,
+        DailyLimit: 5,
+    }
+
+    camps, err := campaign.LoadCampaigns("")
+    if err != nil {
+        logger.Warn("could not load campaigns, using defaults", "error", err)
+        return fallback
+    }
+    c := campaign.GetCampaignByID(camps, id)
+    if c == nil {
+        logger.Warn("campaign not found, using defaults", logging.KeyCampaign, id)
+        return fallback
+    }
+    if err := c.ValidateSequence(); err != nil {
+        fatalf("invalid campaign sequence: %v", err)
+    }
+    logger.Info("using campaign", logging.KeyCampaign, c.ID, "name", c.Name)
+    return *c
 }
 
 // ---------------- ENV ----------------
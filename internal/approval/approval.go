@@ -0,0 +1,265 @@
+package approval
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+)
+
+// Kind is what kind of text is reviewed
+type Kind string
+
+const (
+	KindMessage Kind = "message"
+	KindNote    Kind = "note"
+)
+
+// Status is where an item is in the review
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusRejected Status = "rejected"
+	// StatusSent items were approved and have gone out
+	StatusSent Status = "sent"
+)
+
+// Item is one rendered message or connect note awaiting review. Key ties
+// it to what will send it: the pending message ID, or the campaign for a
+// connect note.
+type Item struct {
+	ID         string `json:"id"`
+	Kind       Kind   `json:"kind"`
+	Key        string `json:"key"`
+	ProfileURL string `json:"profile_url"`
+	CampaignID string `json:"campaign_id,omitempty"`
+	TemplateID string `json:"template_id,omitempty"`
+	Text       string `json:"text"`
+	// Original is the rendered text before the first edit
+	Original   string     `json:"original,omitempty"`
+	Status     Status     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	EditedBy   string     `json:"edited_by,omitempty"`
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	SentAt     *time.Time `json:"sent_at,omitempty"`
+}
+
+// ErrNotFound is returned for an unknown item ID
+var ErrNotFound = errors.New("approval item not found")
+
+// ErrNotPending is returned when reviewing an item that was already decided
+var ErrNotPending = errors.New("approval item already reviewed")
+
+// Path returns the review queue file
+func Path() string {
+	return datadir.Path("approvals.json")
+}
+
+var mu sync.Mutex
+
+func load(path string) ([]Item, error) {
+	if path == "" {
+		path = Path()
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []Item{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var arr []Item
+	return arr, json.Unmarshal(b, &arr)
+}
+
+func save(path string, arr []Item) error {
+	if path == "" {
+		path = Path()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(arr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+func newID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// List returns the items with one of statuses (all if none), oldest first
+func List(path string, statuses ...Status) ([]Item, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	arr, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	out := []Item{}
+	for _, it := range arr {
+		if len(statuses) == 0 || hasStatus(statuses, it.Status) {
+			out = append(out, it)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func hasStatus(in []Status, s Status) bool {
+	for _, x := range in {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the newest item for kind, profile and key that has not
+// been sent yet
+func Find(path string, kind Kind, profileURL, key string) (Item, bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	arr, err := load(path)
+	if err != nil {
+		return Item{}, false, err
+	}
+	for i := len(arr) - 1; i >= 0; i-- {
+		it := arr[i]
+		if it.Kind == kind && it.ProfileURL == profileURL && it.Key == key && it.Status != StatusSent {
+			return it, true, nil
+		}
+	}
+	return Item{}, false, nil
+}
+
+// Submit queues it for review unless an unsent item for the same kind,
+// profile and key exists, which is returned instead
+func Submit(path string, it Item) (Item, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	arr, err := load(path)
+	if err != nil {
+		return Item{}, err
+	}
+	for _, x := range arr {
+		if x.Kind == it.Kind && x.ProfileURL == it.ProfileURL && x.Key == it.Key && x.Status != StatusSent {
+			return x, nil
+		}
+	}
+	it.ID = newID()
+	it.Status = StatusPending
+	it.CreatedAt = time.Now()
+	return it, save(path, append(arr, it))
+}
+
+// update applies fn to the item with id and saves the queue
+func update(path, id string, fn func(it *Item) error) (Item, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	arr, err := load(path)
+	if err != nil {
+		return Item{}, err
+	}
+	for i := range arr {
+		if arr[i].ID != id {
+			continue
+		}
+		if err := fn(&arr[i]); err != nil {
+			return arr[i], err
+		}
+		return arr[i], save(path, arr)
+	}
+	return Item{}, fmt.Errorf("%s: %w", id, ErrNotFound)
+}
+
+func pending(it *Item) error {
+	if it.Status != StatusPending {
+		return fmt.Errorf("%s is %s: %w", it.ID, it.Status, ErrNotPending)
+	}
+	return nil
+}
+
+// Edit replaces a pending item's text; it still needs approval
+func Edit(path, id, by, text string) (Item, error) {
+	return update(path, id, func(it *Item) error {
+		if err := pending(it); err != nil {
+			return err
+		}
+		if text == "" {
+			return fmt.Errorf("%s: edited text is empty", id)
+		}
+		if it.Original == "" && text != it.Text {
+			it.Original = it.Text
+		}
+		it.Text = text
+		it.EditedBy = by
+		return nil
+	})
+}
+
+// Approve marks a pending item approved by by. A non-empty text is
+// applied as an edit first.
+func Approve(path, id, by, text string) (Item, error) {
+	if by == "" {
+		return Item{}, errors.New("approve: approver name is required")
+	}
+	return update(path, id, func(it *Item) error {
+		if err := pending(it); err != nil {
+			return err
+		}
+		if text != "" && text != it.Text {
+			if it.Original == "" {
+				it.Original = it.Text
+			}
+			it.Text = text
+			it.EditedBy = by
+		}
+		now := time.Now()
+		it.Status, it.ReviewedBy, it.ReviewedAt = StatusApproved, by, &now
+		return nil
+	})
+}
+
+// Reject marks a pending item rejected by by; it will not be sent
+func Reject(path, id, by, reason string) (Item, error) {
+	if by == "" {
+		return Item{}, errors.New("reject: reviewer name is required")
+	}
+	return update(path, id, func(it *Item) error {
+		if err := pending(it); err != nil {
+			return err
+		}
+		now := time.Now()
+		it.Status, it.ReviewedBy, it.ReviewedAt, it.Reason = StatusRejected, by, &now, reason
+		return nil
+	})
+}
+
+// MarkSent records that an approved item went out
+func MarkSent(path, id string) error {
+	_, err := update(path, id, func(it *Item) error {
+		now := time.Now()
+		it.Status, it.SentAt = StatusSent, &now
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,133 @@
+package approval
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/webguard"
+)
+
+// DefaultAddr keeps the review page on the local machine
+const DefaultAddr = "127.0.0.1:8090"
+
+var page = template.Must(template.New("approvals").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Approval queue</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", sans-serif; background: #f5f7fa; color: #333; margin: 0; padding: 24px; }
+  h1 { color: #0f3460; font-size: 22px; }
+  .item { background: white; border: 1px solid #e8ecf1; border-radius: 8px; padding: 16px; margin-bottom: 16px; max-width: 760px; }
+  .meta { font-size: 13px; color: #666; margin-bottom: 8px; }
+  textarea { width: 100%; min-height: 90px; font: inherit; padding: 8px; box-sizing: border-box; }
+  input[type=text] { font: inherit; padding: 6px; }
+  button { font: inherit; padding: 6px 14px; margin-right: 6px; border-radius: 6px; border: 1px solid #0f3460; cursor: pointer; }
+  .approve { background: #0f3460; color: white; }
+  .flash { background: #d4edda; border: 1px solid #c3e6cb; padding: 10px; border-radius: 6px; max-width: 760px; }
+  .error { background: #f8d7da; border-color: #f5c6cb; }
+</style>
+</head>
+<body>
+<h1>Approval queue ({{len .Items}} pending)</h1>
+{{if .Flash}}<p class="flash{{if .Error}} error{{end}}">{{.Flash}}</p>{{end}}
+{{range .Items}}
+<form class="item" method="post" action="/review">
+  <div class="meta">{{.Kind}} · <a href="{{.ProfileURL}}">{{.ProfileURL}}</a>{{if .CampaignID}} · campaign {{.CampaignID}}{{end}}{{if .TemplateID}} · template {{.TemplateID}}{{end}} · queued {{.CreatedAt.Format "2006-01-02 15:04"}}{{if .EditedBy}} · edited by {{.EditedBy}}{{end}}</div>
+  <input type="hidden" name="id" value="{{.ID}}">
+  <textarea name="text">{{.Text}}</textarea>
+  <p>
+    Reviewer <input type="text" name="by" value="{{$.Reviewer}}" required>
+    Reason <input type="text" name="reason" placeholder="for rejections">
+  </p>
+  <button class="approve" name="action" value="approve">Approve</button>
+  <button name="action" value="edit">Save edit</button>
+  <button name="action" value="reject">Reject</button>
+</form>
+{{else}}
+<p>Nothing to review.</p>
+{{end}}
+</body>
+</html>
+`))
+
+type pageData struct {
+	Items    []Item
+	Flash    string
+	Error    bool
+	Reviewer string
+}
+
+// WebConfig configures the review page
+type WebConfig struct {
+	// Path is the approval queue (Path() if empty)
+	Path string
+	// Guard checks the host, origin and optional token of every request
+	Guard webguard.Guard
+	// Logger receives the review log lines (slog.Default() if nil)
+	Logger *slog.Logger
+}
+
+// Handler serves the review page for the queue. Reviews are posted to
+// /review; the reviewer's name is remembered in a cookie.
+func Handler(cfg WebConfig) http.Handler {
+	path := cfg.Path
+	lg := logging.Or(cfg.Logger).With(logging.KeyAction, "approval")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		render(w, r, lg, path, r.URL.Query().Get("flash"), r.URL.Query().Get("error") != "")
+	})
+	mux.HandleFunc("/review", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		id, by := r.FormValue("id"), r.FormValue("by")
+		var (
+			it  Item
+			err error
+		)
+		switch r.FormValue("action") {
+		case "approve":
+			it, err = Approve(path, id, by, r.FormValue("text"))
+		case "edit":
+			it, err = Edit(path, id, by, r.FormValue("text"))
+		case "reject":
+			it, err = Reject(path, id, by, r.FormValue("reason"))
+		default:
+			http.Error(w, "unknown action", http.StatusBadRequest)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "reviewer", Value: by, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+		if err != nil {
+			lg.Warn("could not review approval", "approval", id, "error", err)
+			http.Redirect(w, r, "/?error=1&flash="+template.URLQueryEscaper(err.Error()), http.StatusSeeOther)
+			return
+		}
+		lg.Info("approval reviewed", "approval", it.ID, "review", r.FormValue("action"), "by", by)
+		http.Redirect(w, r, "/?flash="+template.URLQueryEscaper(it.ID+": "+r.FormValue("action")+" saved"), http.StatusSeeOther)
+	})
+	return cfg.Guard.Wrap(mux)
+}
+
+func render(w http.ResponseWriter, r *http.Request, lg *slog.Logger, path, flash string, isErr bool) {
+	items, err := List(path, StatusPending)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data := pageData{Items: items, Flash: flash, Error: isErr}
+	if c, err := r.Cookie("reviewer"); err == nil {
+		data.Reviewer = c.Value
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, data); err != nil {
+		lg.Warn("could not render approval page", "error", err)
+	}
+}
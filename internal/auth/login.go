@@ -1,39 +1,89 @@
 package auth
 
 import (
+	"context"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dom"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/events"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
 )
 
-func Login(page *rod.Page, email, password string) error {
+// LoginTimeout bounds Login when ctx carries no deadline of its own
+const LoginTimeout = 5 * time.Second
+
+func Login(ctx context.Context, page *rod.Page, email, password string) (err error) {
 	if page == nil {
 		return errors.New("page is nil")
 	}
+	ev := events.Start(events.TypeLogin, "", "", false)
+	defer func() {
+		outcome := ""
+		if errors.Is(err, ErrCheckpoint) {
+			outcome = "checkpoint"
+		}
+		ev.Done(outcome, err)
+	}()
 
-	log.Println("Logging in (mock site)")
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, LoginTimeout)
+		defer cancel()
+	}
+	page = page.Context(ctx)
 
-	page.MustElement("#email").MustInput(email)
-	page.MustElement("#password").MustInput(password)
-	page.MustElement("#login-btn").MustClick()
+	slog.Info("logging in (mock site)", logging.KeyAction, "login")
 
-	// Wait for redirect to search.html
-	timeout := time.After(5 * time.Second)
-	tick := time.Tick(200 * time.Millisecond)
+	for _, f := range []struct{ sel, value string }{
+		{"#email", email},
+		{"#password", password},
+	} {
+		el, err := dom.Find(page, f.sel, 0)
+		if err != nil {
+			return fmt.Errorf("login form: %w", err)
+		}
+		if err := el.Input(f.value); err != nil {
+			return fmt.Errorf("fill %s: %w", f.sel, err)
+		}
+	}
+
+	btn, err := dom.Find(page, "#login-btn", 0)
+	if err != nil {
+		return fmt.Errorf("login form: %w", err)
+	}
+	if err := btn.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return fmt.Errorf("click login: %w", err)
+	}
+
+	// Wait for redirect to search.html, failing fast on a security checkpoint
+	tick := time.NewTicker(200 * time.Millisecond)
+	defer tick.Stop()
 
 	for {
 		select {
-		case <-timeout:
-			return errors.New("login timeout")
-		case <-tick:
-			href := page.MustEval(`() => location.href`).String()
-			if strings.Contains(href, "search.html") {
-				log.Println("✓ Login successful")
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return errors.New("login timeout")
+			}
+			return ctx.Err()
+		case <-tick.C:
+			res, err := page.Eval(`() => location.href`)
+			if err != nil {
+				continue // page may be mid-navigation; retry until the deadline
+			}
+			if strings.Contains(res.Value.String(), "search.html") {
+				slog.Info("login successful", logging.KeyAction, "login")
 				return nil
 			}
+			if err := DetectSecurityCheckpoints(ctx, page); errors.Is(err, ErrCheckpoint) {
+				return err
+			}
 		}
 	}
 }
@@ -1,29 +1,69 @@
 package auth
 
 import (
+	"context"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/go-rod/rod"
 )
 
+// Checkpoint kinds reported in CheckpointError
+const (
+	CheckpointTwoFactor = "2fa"
+	CheckpointCaptcha   = "captcha"
+	CheckpointChallenge = "challenge"
+)
+
+// ErrCheckpoint is matched by every CheckpointError
+var ErrCheckpoint = errors.New("security checkpoint")
+
+// CheckpointError reports a security checkpoint that needs a human
+type CheckpointError struct {
+	Kind string
+	URL  string
+}
+
+func (e *CheckpointError) Error() string {
+	msg := map[string]string{
+		CheckpointTwoFactor: "2FA challenge detected - please complete manually",
+		CheckpointCaptcha:   "CAPTCHA detected - please complete manually",
+	}[e.Kind]
+	if msg == "" {
+		msg = "security challenge detected"
+	}
+	if e.URL != "" {
+		return fmt.Sprintf("%s (%s)", msg, e.URL)
+	}
+	return msg
+}
+
+// Is makes CheckpointError match ErrCheckpoint
+func (e *CheckpointError) Is(target error) bool { return target == ErrCheckpoint }
+
 // DetectSecurityCheckpoints checks for 2FA, captcha, or other security challenges
-func DetectSecurityCheckpoints(page *rod.Page) error {
+func DetectSecurityCheckpoints(ctx context.Context, page *rod.Page) error {
 	if page == nil {
 		return nil
 	}
+	page = page.Context(ctx)
+	url := ""
+	if info, err := page.Info(); err == nil {
+		url = info.URL
+	}
 
 	// Check for 2FA challenge
-	if twoFA, err := page.Element("#two-factor-input, #verification-code, .two-factor"); err == nil && twoFA != nil {
-		log.Println("⚠️ 2FA challenge detected - manual intervention required")
-		return errors.New("2FA challenge detected - please complete manually")
+	if has, _, err := page.Has("#two-factor-input, #verification-code, .two-factor"); err == nil && has {
+		slog.Warn("2FA challenge detected, manual intervention required", "url", url)
+		return &CheckpointError{Kind: CheckpointTwoFactor, URL: url}
 	}
 
 	// Check for CAPTCHA
-	if captcha, err := page.Element("#captcha, .g-recaptcha, .captcha-container, [data-callback]"); err == nil && captcha != nil {
-		log.Println("⚠️ CAPTCHA detected - manual intervention required")
-		return errors.New("CAPTCHA detected - please complete manually")
+	if has, _, err := page.Has("#captcha, .g-recaptcha, .captcha-container, [data-callback]"); err == nil && has {
+		slog.Warn("CAPTCHA detected, manual intervention required", "url", url)
+		return &CheckpointError{Kind: CheckpointCaptcha, URL: url}
 	}
 
 	// Check for security challenge text
@@ -34,8 +74,8 @@ func DetectSecurityCheckpoints(page *rod.Page) error {
 		if strings.Contains(bodyLower, "verify your identity") ||
 			strings.Contains(bodyLower, "security challenge") ||
 			strings.Contains(bodyLower, "verify it's you") {
-			log.Println("⚠️ Security challenge detected - manual intervention may be required")
-			return errors.New("security challenge detected")
+			slog.Warn("security challenge detected, manual intervention may be required", "url", url)
+			return &CheckpointError{Kind: CheckpointChallenge, URL: url}
 		}
 	}
 
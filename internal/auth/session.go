@@ -1,8 +1,9 @@
 package auth
 
 import (
+	"context"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"strings"
 
 	"github.com/go-rod/rod"
@@ -10,30 +11,32 @@ import (
 
 // SaveCookies saves document.cookie string to a local file.
 // This is a simple persistence mechanism for the mock site.
-func SaveCookies(page *rod.Page, path string) error {
+func SaveCookies(ctx context.Context, page *rod.Page, path string) error {
 	if page == nil {
 		return nil
 	}
+	page = page.Context(ctx)
 	// Use Eval to retrieve document.cookie as a string (with error handling)
 	result, err := page.Eval("() => document.cookie")
 	if err != nil {
-		log.Printf("warning: could not read cookies: %v", err)
+		slog.Warn("could not read cookies", "error", err)
 		return err
 	}
 	cookieStr := result.Value.String()
 	if err := ioutil.WriteFile(path, []byte(cookieStr), 0o644); err != nil {
 		return err
 	}
-	log.Printf("saved cookies to %s", path)
+	slog.Debug("saved cookies", "path", path)
 	return nil
 }
 
 // LoadCookies reads cookie string from file and sets document.cookie entries on the page.
 // It does not attempt to validate domains; caller should navigate to the appropriate page first.
-func LoadCookies(page *rod.Page, path string) error {
+func LoadCookies(ctx context.Context, page *rod.Page, path string) error {
 	if page == nil {
 		return nil
 	}
+	page = page.Context(ctx)
 	b, err := ioutil.ReadFile(path)
 	if err != nil {
 		return err
@@ -48,9 +51,9 @@ func LoadCookies(page *rod.Page, path string) error {
 		// set each cookie via document.cookie
 		js := `document.cookie = "` + p + `; path=/";`
 		if _, err := page.Eval(js); err != nil {
-			log.Printf("warning: could not set cookie: %v", err)
+			slog.Warn("could not set cookie", "error", err)
 		}
 	}
-	log.Printf("loaded cookies from %s", path)
+	slog.Debug("loaded cookies", "path", path)
 	return nil
 }
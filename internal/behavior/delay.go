@@ -47,8 +47,13 @@ func HumanType(el *rod.Element, text string) error {
 		_, _ = page.Eval(`(el, txt) => { el.value = txt; }`, el, text)
 	}
 
-	// Simulate reading/thinking time with inter-character delays
+	// Simulate reading/thinking time with inter-character delays,
+	// stopping early if the element's context is cancelled
+	ctx := el.GetContext()
 	for range text {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		SleepHuman(80*time.Millisecond, 220*time.Millisecond)
 	}
 
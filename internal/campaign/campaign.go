@@ -0,0 +1,69 @@
+package campaign
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/ratelimit"
+)
+
+// Search is one query the campaign runs on the search page.
+// Type is one of name, company, location or position.
+type Search struct {
+	Query string `json:"query"`
+	Type  string `json:"type"`
+}
+
+// Campaign groups the per-campaign outreach settings
+type Campaign struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Note is the connection note template sent with each invitation
+	Note       string `json:"note"`
+	NoteLimit  int    `json:"note_limit"`
+	DailyLimit int    `json:"daily_limit"`
+	// FollowUpTemplateID is enqueued as a pending message after a successful
+	// connect; ignored when Sequence is set
+	FollowUpTemplateID string `json:"follow_up_template_id"`
+	// Sequence lists the outreach steps: a connect, then messages with
+	// their delay and condition (see Steps)
+	Sequence []Step `json:"sequence,omitempty"`
+	// WithdrawAfterDays is how long a request may stay pending before the withdraw stage removes it
+	WithdrawAfterDays int `json:"withdraw_after_days"`
+	// Searches run on every campaign pass (run command and each daemon cycle)
+	Searches []Search `json:"searches"`
+	// SendWindow, if set, defers connects and messages to allowed hours
+	SendWindow *SendWindow `json:"send_window,omitempty"`
+	// RequireApproval holds rendered messages and connect notes in the
+	// approval queue until a reviewer approves them
+	RequireApproval bool `json:"require_approval,omitempty"`
+	// Limits adds hourly/weekly or rolling limits per action ("connect", "message")
+	Limits map[string][]ratelimit.Limit `json:"limits,omitempty"`
+}
+
+// LoadCampaigns reads campaigns from a JSON file
+func LoadCampaigns(path string) ([]Campaign, error) {
+	if path == "" {
+		path = "data/campaigns.json"
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var arr []Campaign
+	if err := json.Unmarshal(b, &arr); err != nil {
+		return nil, err
+	}
+	return arr, nil
+}
+
+// GetCampaignByID returns the campaign with matching id or nil if not found
+func GetCampaignByID(campaigns []Campaign, id string) *Campaign {
+	for _, c := range campaigns {
+		if c.ID == id {
+			copy := c
+			return &copy
+		}
+	}
+	return nil
+}
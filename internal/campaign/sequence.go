@@ -0,0 +1,149 @@
+package campaign
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Step actions
+const (
+	ActionConnect = "connect"
+	ActionMessage = "message"
+)
+
+// Condition decides whether a step may run for a prospect
+type Condition string
+
+const (
+	// ConditionAccepted waits for the connection to be accepted
+	ConditionAccepted Condition = "accepted"
+	// ConditionNoReply also requires that the prospect has not replied;
+	// a reply ends the sequence
+	ConditionNoReply Condition = "no_reply"
+)
+
+// Delay is a step delay written as a Go duration ("12h") or in days ("3d")
+type Delay time.Duration
+
+// UnmarshalJSON accepts "3d", "36h", "90m" or a number of seconds
+func (d *Delay) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		var secs float64
+		if err := json.Unmarshal(b, &secs); err != nil {
+			return fmt.Errorf("delay: expected a string like \"3d\" or \"12h\"")
+		}
+		*d = Delay(time.Duration(secs * float64(time.Second)))
+		return nil
+	}
+	v, err := parseDelay(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// MarshalJSON writes the delay in the form UnmarshalJSON reads
+func (d Delay) MarshalJSON() ([]byte, error) {
+	dur := time.Duration(d)
+	if dur > 0 && dur%(24*time.Hour) == 0 {
+		return json.Marshal(strconv.Itoa(int(dur/(24*time.Hour))) + "d")
+	}
+	return json.Marshal(dur.String())
+}
+
+func parseDelay(s string) (Delay, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("delay %q: invalid number of days", s)
+		}
+		return Delay(time.Duration(n * float64(24*time.Hour))), nil
+	}
+	dur, err := time.ParseDuration(s)
+	if err != nil || dur < 0 {
+		return 0, fmt.Errorf("delay %q: expected e.g. \"3d\" or \"12h\"", s)
+	}
+	return Delay(dur), nil
+}
+
+// Step is one action of an outreach sequence. Delay counts from the
+// previous step (for the first message: from the connect request).
+type Step struct {
+	Action     string    `json:"action"`
+	TemplateID string    `json:"template_id,omitempty"`
+	Delay      Delay     `json:"delay,omitempty"`
+	Condition  Condition `json:"condition,omitempty"`
+}
+
+// Steps returns the campaign's sequence. Campaigns without one get the
+// legacy single follow-up: connect, then FollowUpTemplateID once accepted.
+func (c Campaign) Steps() []Step {
+	if len(c.Sequence) > 0 {
+		return c.Sequence
+	}
+	steps := []Step{{Action: ActionConnect}}
+	if c.FollowUpTemplateID != "" {
+		steps = append(steps, Step{Action: ActionMessage, TemplateID: c.FollowUpTemplateID, Condition: ConditionAccepted})
+	}
+	return steps
+}
+
+// NextMessage returns the first message step after step index after, and
+// its index; ok is false when the sequence is over
+func (c Campaign) NextMessage(after int) (int, Step, bool) {
+	steps := c.Steps()
+	for i := after + 1; i < len(steps); i++ {
+		if steps[i].Action == ActionMessage {
+			return i, steps[i], true
+		}
+	}
+	return 0, Step{}, false
+}
+
+// StepIndex returns the index of the message step that sends templateID,
+// used for queue entries written before sequences existed
+func (c Campaign) StepIndex(templateID string) (int, bool) {
+	for i, s := range c.Steps() {
+		if s.Action == ActionMessage && s.TemplateID == templateID {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// ValidateSequence checks that the sequence starts with a connect and
+// that every message step names a template and a known condition
+func (c Campaign) ValidateSequence() error {
+	for i, s := range c.Sequence {
+		switch s.Action {
+		case ActionConnect:
+			if i != 0 {
+				return fmt.Errorf("campaign %s: sequence step %d: connect must be the first step", c.ID, i+1)
+			}
+		case ActionMessage:
+			if i == 0 {
+				return fmt.Errorf("campaign %s: sequence step 1: must be a connect step", c.ID)
+			}
+			if s.TemplateID == "" {
+				return fmt.Errorf("campaign %s: sequence step %d: template_id is required", c.ID, i+1)
+			}
+		default:
+			return fmt.Errorf("campaign %s: sequence step %d: unknown action %q (expected connect or message)", c.ID, i+1, s.Action)
+		}
+		switch s.Condition {
+		case "", ConditionAccepted, ConditionNoReply:
+		default:
+			return fmt.Errorf("campaign %s: sequence step %d: unknown condition %q (expected accepted or no_reply)", c.ID, i+1, s.Condition)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package campaign
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+)
+
+// State is the run state an operator sets on a campaign, kept apart from
+// the campaign configuration
+type State struct {
+	// Paused campaigns send no connects, messages or engagement
+	Paused    bool      `json:"paused"`
+	By        string    `json:"by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StatePath returns the campaign state file
+func StatePath() string {
+	return datadir.Path("campaign_state.json")
+}
+
+var stateMu sync.Mutex
+
+// LoadStates returns the state of every campaign that has one, by ID
+func LoadStates(path string) (map[string]State, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	return loadStates(path)
+}
+
+func loadStates(path string) (map[string]State, error) {
+	if path == "" {
+		path = StatePath()
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]State{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]State{}
+	return m, json.Unmarshal(b, &m)
+}
+
+// IsPaused reports whether campaign id is paused
+func IsPaused(path, id string) (bool, error) {
+	m, err := LoadStates(path)
+	if err != nil {
+		return false, err
+	}
+	return m[id].Paused, nil
+}
+
+// SetPaused pauses or resumes campaign id on behalf of by
+func SetPaused(path, id string, paused bool, by string) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	m, err := loadStates(path)
+	if err != nil {
+		return err
+	}
+	m[id] = State{Paused: paused, By: by, UpdatedAt: time.Now()}
+
+	if path == "" {
+		path = StatePath()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
@@ -0,0 +1,133 @@
+package campaign
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SendWindow restricts when actions may run, e.g. weekdays 09:00–17:00.
+// Times are interpreted in the prospect's timezone when UseProspectTimezone
+// is set and one is known, otherwise in Timezone (default: operator's local time).
+type SendWindow struct {
+	// Days lists allowed weekdays as "mon".."sun"; empty means every day
+	Days                []string `json:"days"`
+	Start               string   `json:"start"`
+	End                 string   `json:"end"`
+	Timezone            string   `json:"timezone"`
+	UseProspectTimezone bool     `json:"use_prospect_timezone"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// atClock returns the wall-clock time offset into day, staying correct across DST changes
+func atClock(day time.Time, offset time.Duration) time.Time {
+	h, m := int(offset/time.Hour), int(offset%time.Hour/time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+}
+
+// Location returns the timezone the window is evaluated in
+func (w SendWindow) Location(prospectTZ string) (*time.Location, error) {
+	name := w.Timezone
+	if w.UseProspectTimezone && prospectTZ != "" {
+		name = prospectTZ
+	}
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("send window timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// NextOpen returns t if it falls inside the window, otherwise the start
+// of the next open slot.
+func (w SendWindow) NextOpen(t time.Time, prospectTZ string) (time.Time, error) {
+	loc, err := w.Location(prospectTZ)
+	if err != nil {
+		return t, err
+	}
+
+	start, end := time.Duration(0), 24*time.Hour
+	if w.Start != "" {
+		if start, err = parseClock(w.Start); err != nil {
+			return t, err
+		}
+	}
+	if w.End != "" {
+		if end, err = parseClock(w.End); err != nil {
+			return t, err
+		}
+	}
+	if end <= start {
+		return t, fmt.Errorf("send window end %s must be after start %s", w.End, w.Start)
+	}
+
+	allowed := map[time.Weekday]bool{}
+	for _, d := range w.Days {
+		key := strings.ToLower(strings.TrimSpace(d))
+		if len(key) > 3 {
+			key = key[:3] // accept "monday" as well as "mon"
+		}
+		wd, ok := weekdays[key]
+		if !ok {
+			return t, fmt.Errorf("send window: unknown day %q", d)
+		}
+		allowed[wd] = true
+	}
+
+	local := t.In(loc)
+	for i := 0; i < 8; i++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+i, 0, 0, 0, 0, loc)
+		if len(allowed) > 0 && !allowed[day.Weekday()] {
+			continue
+		}
+		opens, closes := atClock(day, start), atClock(day, end)
+		if local.Before(opens) {
+			return opens, nil
+		}
+		if local.Before(closes) {
+			return t, nil
+		}
+	}
+	return t, fmt.Errorf("send window has no open slot")
+}
+
+// usStateTimezones maps US state abbreviations to an IANA timezone
+var usStateTimezones = map[string]string{
+	"CA": "America/Los_Angeles", "WA": "America/Los_Angeles", "OR": "America/Los_Angeles", "NV": "America/Los_Angeles",
+	"AZ": "America/Phoenix",
+	"CO": "America/Denver", "UT": "America/Denver", "NM": "America/Denver",
+	"TX": "America/Chicago", "IL": "America/Chicago", "MN": "America/Chicago", "MO": "America/Chicago",
+	"NY": "America/New_York", "MA": "America/New_York", "NJ": "America/New_York", "PA": "America/New_York",
+	"GA": "America/New_York", "FL": "America/New_York", "NC": "America/New_York", "VA": "America/New_York",
+	"DC": "America/New_York",
+}
+
+// TimezoneForLocation guesses a timezone from a "City, ST" profile location.
+// It returns "" when the location is not recognised.
+func TimezoneForLocation(location string) string {
+	i := strings.LastIndex(location, ",")
+	if i < 0 {
+		return ""
+	}
+	return usStateTimezones[strings.ToUpper(strings.TrimSpace(location[i+1:]))]
+}
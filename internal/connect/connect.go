@@ -1,31 +1,125 @@
 package connect
 
 import (
+	"context"
 	"encoding/json"
-
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/approval"
 	"github.com/sushmitaRN/linkedin-automation-poc/internal/behavior"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dnc"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dom"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dryrun"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/events"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/message"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/prospect"
 	"github.com/sushmitaRN/linkedin-automation-poc/internal/ratelimit"
 )
 
+// DefaultNoteLimit is the maximum invitation note length, in characters
+const DefaultNoteLimit = 300
+
 // ConnectConfig controls connect behavior
 type ConnectConfig struct {
-	DailyLimit  int
+	// DailyLimit and Limits are the campaign-scope connect limits
+	DailyLimit int
+	Limits     []ratelimit.Limit
+	// Account selects the account-scope limits in data/limits.json
+	Account     string
 	StoragePath string
+	// Note is an optional invitation note template using {{var}} tokens
+	Note      string
+	NoteLimit int
+	// CampaignID and FollowUpTemplateID configure the follow-up message
+	// enqueued in PendingPath after a successful connect. FollowUpStep is
+	// its campaign sequence step; it is not sent before FollowUpDelay.
+	CampaignID         string
+	FollowUpTemplateID string
+	FollowUpStep       int
+	FollowUpDelay      time.Duration
+	PendingPath        string
+	// DryRun stops before the connect click and logs the request to the
+	// dry-run log instead; nothing is recorded or counted
+	DryRun bool
+	// RequireApproval sends a note only once a reviewer has approved it in
+	// the approval queue; until then the connect is not attempted
+	RequireApproval bool
+	// Logger receives the connect log lines (slog.Default() if nil)
+	Logger *slog.Logger
 }
 
+// record stores req unless this is a dry run
+func (cfg ConnectConfig) record(req SentRequest) {
+	if cfg.DryRun {
+		return
+	}
+	if err := recordSent(cfg.StoragePath, req); err != nil {
+		logging.Or(cfg.Logger).Warn("could not save sent request", logging.KeyProspect, req.ProfileURL, "error", err)
+	}
+}
+
+// Outcome is the verified result of a connect attempt
+type Outcome string
+
+const (
+	OutcomeSent             Outcome = "sent"
+	OutcomeAlreadyPending   Outcome = "already_pending"
+	OutcomeAlreadyConnected Outcome = "already_connected"
+	OutcomeButtonMissing    Outcome = "button_missing"
+	OutcomeFailed           Outcome = "failed"
+	// OutcomeDryRun means everything but the final click was done
+	OutcomeDryRun Outcome = "dry_run"
+	// OutcomeBlocked means the prospect is on the do-not-contact list
+	OutcomeBlocked Outcome = "blocked"
+	// OutcomeAwaitingApproval means the note is waiting for a reviewer
+	OutcomeAwaitingApproval Outcome = "awaiting_approval"
+	// OutcomeRejected means a reviewer rejected the note
+	OutcomeRejected Outcome = "rejected"
+)
+
+// RequestStatus tracks a sent request after the connect attempt
+type RequestStatus string
+
+const (
+	StatusPending   RequestStatus = "pending"
+	StatusAccepted  RequestStatus = "accepted"
+	StatusWithdrawn RequestStatus = "withdrawn"
+)
+
 // SentRequest stores a sent connect request record
 type SentRequest struct {
-	ProfileURL string    `json:"profile_url"`
-	Timestamp  time.Time `json:"timestamp"`
+	ProfileURL string        `json:"profile_url"`
+	Note       string        `json:"note,omitempty"`
+	Outcome    Outcome       `json:"outcome,omitempty"`
+	Status     RequestStatus `json:"status,omitempty"`
+	ApprovedBy string        `json:"approved_by,omitempty"`
+	Timestamp  time.Time     `json:"timestamp"`
+	UpdatedAt  *time.Time    `json:"updated_at,omitempty"`
 }
 
+// ---------------- SELECTORS ----------------
+
+const (
+	selectorConnectButton    = "#connect-btn"
+	selectorConnectStatus    = "#connect-status"
+	selectorNoteDialog       = "#note-dialog"
+	selectorAddNoteButton    = "#add-note-btn"
+	selectorNoteInput        = "#note-input"
+	selectorSendInviteButton = "#send-invite-btn"
+	selectorSendWithoutNote  = "#send-without-note-btn"
+)
+
 // ---------------- STORAGE ----------------
 
 func ensureStorageDir(path string) error {
@@ -55,47 +149,373 @@ func saveSent(path string, arr []SentRequest) error {
 	return os.WriteFile(path, b, 0o644)
 }
 
+// sentMu serialises writes to the sent requests file; connect and
+// withdraw jobs may run at the same time
+var sentMu sync.Mutex
+
+// updateSent loads the sent requests, applies fn and saves the result
+// while holding sentMu, so concurrent records and status updates are not lost
+func updateSent(path string, fn func([]SentRequest) []SentRequest) error {
+	sentMu.Lock()
+	defer sentMu.Unlock()
+
+	arr, err := loadSent(path)
+	if err != nil {
+		return err
+	}
+	return saveSent(path, fn(arr))
+}
+
+// recordSent appends req. A profile found already pending or connected is
+// recorded once per outcome, so repeated runs do not pile up skips.
+func recordSent(path string, req SentRequest) error {
+	return updateSent(path, func(arr []SentRequest) []SentRequest {
+		if req.Outcome == OutcomeAlreadyPending || req.Outcome == OutcomeAlreadyConnected {
+			for _, r := range arr {
+				if r.ProfileURL == req.ProfileURL && r.Outcome == req.Outcome {
+					return arr
+				}
+			}
+		}
+		return append(arr, req)
+	})
+}
+
+// ---------------- NOTE ----------------
+
+// RenderNote renders the note template with vars and checks it against
+// the content policy, with limit as the character limit.
+// An empty template yields an empty note.
+func RenderNote(tpl string, vars map[string]string, limit int) (string, error) {
+	if tpl == "" {
+		return "", nil
+	}
+	if limit <= 0 {
+		limit = DefaultNoteLimit
+	}
+
+	policy, err := message.LoadPolicy("")
+	if err != nil {
+		return "", fmt.Errorf("load policy: %w", err)
+	}
+	policy.MaxRunes = limit
+
+	note, err := policy.Render(tpl, vars)
+	if err != nil {
+		return "", fmt.Errorf("note: %w", err)
+	}
+	return note, nil
+}
+
+// sendInvite completes the invitation dialog shown after clicking connect.
+// Pages without a note dialog (e.g. company pages) send on click, so a
+// missing dialog is not an error.
+func sendInvite(page *rod.Page, note string, lg *slog.Logger) (bool, error) {
+	if _, err := dom.Find(page, selectorNoteDialog+".open", 3*time.Second); err != nil {
+		if errors.Is(err, dom.ErrElementNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if note == "" {
+		btn, err := dom.Find(page, selectorSendWithoutNote, 0)
+		if err != nil {
+			return false, err
+		}
+		return false, btn.Click(proto.InputMouseButtonLeft, 1)
+	}
+
+	addBtn, err := dom.Find(page, selectorAddNoteButton, 0)
+	if err != nil {
+		return false, err
+	}
+	if err := addBtn.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return false, err
+	}
+
+	input, err := dom.Find(page, selectorNoteInput, 0)
+	if err != nil {
+		return false, err
+	}
+	lg.Debug("typing connection note", "chars", utf8.RuneCountInString(note))
+	if err := behavior.HumanType(input, note); err != nil {
+		return false, err
+	}
+
+	behavior.ReadingPause()
+
+	sendBtn, err := dom.Find(page, selectorSendInviteButton, 0)
+	if err != nil {
+		return false, err
+	}
+	if err := sendBtn.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // ---------------- CONNECT ----------------
 
-// Connect assumes the PROFILE PAGE IS ALREADY OPEN
-func Connect(page *rod.Page, profileURL string, cfg ConnectConfig) error {
+// QuotaChain returns the global → account → campaign limits a connect is checked against
+func (cfg ConnectConfig) QuotaChain() ([]ratelimit.ScopeLimits, error) {
+	qc, err := ratelimit.LoadConfig("")
+	if err != nil {
+		return nil, fmt.Errorf("load limits: %w", err)
+	}
+	campaignID := cfg.CampaignID
+	if campaignID == "" {
+		campaignID = "default"
+	}
+	limits := cfg.Limits
+	if cfg.DailyLimit > 0 {
+		limits = append([]ratelimit.Limit{ratelimit.Daily(cfg.DailyLimit)}, limits...)
+	}
+	return qc.Chain("connect", cfg.Account, ratelimit.ScopeLimits{
+		Scope:  ratelimit.CampaignScope(campaignID),
+		Limits: limits,
+	}), nil
+}
+
+// reviewNote looks the note up in the approval queue. It returns the
+// approved item, or the outcome to stop with: the note is queued for
+// review the first time and the connect waits until it is approved.
+func (cfg ConnectConfig) reviewNote(profileURL, note string, lg *slog.Logger) (approval.Item, Outcome, error) {
+	it, found, err := approval.Find("", approval.KindNote, profileURL, cfg.CampaignID)
+	if err != nil {
+		return it, OutcomeFailed, fmt.Errorf("approval queue: %w", err)
+	}
+	if !found {
+		if cfg.DryRun {
+			lg.Info("dry run: would queue note for approval")
+			return it, OutcomeAwaitingApproval, nil
+		}
+		it, err = approval.Submit("", approval.Item{
+			Kind:       approval.KindNote,
+			Key:        cfg.CampaignID,
+			ProfileURL: profileURL,
+			CampaignID: cfg.CampaignID,
+			Text:       note,
+		})
+		if err != nil {
+			return it, OutcomeFailed, fmt.Errorf("approval queue: %w", err)
+		}
+		lg.Info("note queued for approval", "approval_id", it.ID)
+		return it, OutcomeAwaitingApproval, nil
+	}
+
+	switch it.Status {
+	case approval.StatusApproved:
+		// an edited note must still pass the policy
+		if _, err := RenderNote(it.Text, nil, cfg.NoteLimit); err != nil {
+			return it, OutcomeFailed, fmt.Errorf("approved note %s: %w", it.ID, err)
+		}
+		return it, "", nil
+	case approval.StatusRejected:
+		lg.Info("note rejected", "approval_id", it.ID, "reviewed_by", it.ReviewedBy, "reason", it.Reason)
+		if !cfg.DryRun {
+			if err := prospect.MarkSkipped("", profileURL, "note rejected by "+it.ReviewedBy); err != nil {
+				lg.Warn("could not record skip", "error", err)
+			}
+		}
+		return it, OutcomeRejected, nil
+	}
+	return it, OutcomeAwaitingApproval, nil
+}
+
+// eventOutcome is the event log outcome for a connect attempt: the
+// connect outcome, or the error's class when the attempt failed
+func eventOutcome(outcome Outcome, err error) string {
+	if err != nil && (outcome == OutcomeFailed || outcome == "") {
+		return events.OutcomeOf(err)
+	}
+	return string(outcome)
+}
+
+// connectTarget describes the open person or company page for the
+// do-not-contact check
+func connectTarget(page *rod.Page, profileURL string, vars map[string]string) dnc.Target {
+	t := dnc.Target{ProfileURL: profileURL, Name: dom.Text(page, "#name"), Company: vars["company"]}
+	if name := dom.Text(page, "#company-name"); name != "" {
+		t.Company = name
+	}
+	if t.Company == "" {
+		t.Company = dom.Text(page, "#company")
+	}
+	return t
+}
+
+// Connect assumes the PROFILE PAGE IS ALREADY OPEN.
+// vars are used to render cfg.Note, if set. The returned Outcome is
+// verified against #connect-status and stored with the record.
+func Connect(ctx context.Context, page *rod.Page, profileURL string, vars map[string]string, cfg ConnectConfig) (outcome Outcome, err error) {
+	ev := events.Start(events.TypeConnect, profileURL, cfg.CampaignID, cfg.DryRun)
+	defer func() { ev.Done(eventOutcome(outcome, err), err) }()
+	lg := logging.Or(cfg.Logger).With(logging.KeyAction, "connect", logging.KeyProspect, profileURL, logging.KeyCampaign, cfg.CampaignID)
+
+	page = page.Context(ctx)
 	if cfg.DailyLimit <= 0 {
 		cfg.DailyLimit = 5
 	}
 	if cfg.StoragePath == "" {
-		cfg.StoragePath = "data/sent_requests.json"
+		cfg.StoragePath = datadir.Path("sent_requests.json")
+	}
+	if cfg.PendingPath == "" {
+		cfg.PendingPath = datadir.Path("pending_messages.json")
 	}
 
-	// Rate limit
-	if err := ratelimit.CheckAndIncrement("connect", cfg.DailyLimit, "data/quotas.json"); err != nil {
-		return err
+	// Refuse prospects on the do-not-contact list before anything else
+	if err := dnc.Guard("connect", connectTarget(page, profileURL, vars), cfg.DryRun, lg); err != nil {
+		return OutcomeBlocked, err
+	}
+
+	// Render note before touching the quota so a bad template costs nothing
+	note, err := RenderNote(cfg.Note, vars, cfg.NoteLimit)
+	if err != nil {
+		return OutcomeFailed, err
+	}
+
+	// Skip profiles that already have a pending request or connection
+	if outcome, ok := classifyState(readConnectState(page)); ok {
+		lg.Info("connect skipped", "outcome", outcome)
+		cfg.record(SentRequest{
+			ProfileURL: profileURL,
+			Outcome:    outcome,
+			Timestamp:  time.Now(),
+		})
+		return outcome, nil
+	}
+
+	// In approval mode only a reviewed note goes out
+	var review approval.Item
+	if cfg.RequireApproval && note != "" {
+		var outcome Outcome
+		review, outcome, err = cfg.reviewNote(profileURL, note, lg)
+		if err != nil || outcome != "" {
+			return outcome, err
+		}
+		note = review.Text
 	}
 
 	// Ensure connect button exists
-	btn := page.MustElement("#connect-btn")
-	btn.MustWaitVisible()
-	btn.MustScrollIntoView()
+	btn, err := dom.Find(page, selectorConnectButton, 5*time.Second)
+	if err != nil {
+		if !errors.Is(err, dom.ErrElementNotFound) {
+			return OutcomeFailed, err
+		}
+		cfg.record(SentRequest{
+			ProfileURL: profileURL,
+			Outcome:    OutcomeButtonMissing,
+			Timestamp:  time.Now(),
+		})
+		return OutcomeButtonMissing, fmt.Errorf("connect button on %s: %w", profileURL, err)
+	}
+
+	// Rate limit
+	chain, err := cfg.QuotaChain()
+	if err != nil {
+		return OutcomeFailed, err
+	}
+	// Hold a quota slot; it is only counted once the page confirms the request
+	tok, err := ratelimit.Reserve("connect", chain, datadir.Path("quotas.json"))
+	if err != nil {
+		return OutcomeFailed, err
+	}
+	defer tok.Release()
+
+	if err := btn.WaitVisible(); err != nil {
+		return OutcomeFailed, fmt.Errorf("connect button on %s: %w", profileURL, err)
+	}
+	if err := btn.ScrollIntoView(); err != nil {
+		return OutcomeFailed, fmt.Errorf("scroll to connect button: %w", err)
+	}
+
+	if cfg.DryRun {
+		lg.Info("dry run: would send connect request")
+		if err := dryrun.Record(dryrun.Entry{
+			Action:     "connect",
+			ProfileURL: profileURL,
+			CampaignID: cfg.CampaignID,
+			Detail:     note,
+		}); err != nil {
+			lg.Warn("could not write dry-run log", "error", err)
+		}
+		return OutcomeDryRun, nil
+	}
 
 	behavior.ThinkPause()
 
 	if err := btn.Click(proto.InputMouseButtonLeft, 1); err != nil {
-		return err
+		return OutcomeFailed, err
 	}
 
-	log.Println("✓ Connect clicked")
+	lg.Debug("connect clicked")
 
-	// Optional confirmation element
-	page.MustWaitIdle()
+	noteSent, err := sendInvite(page, note, lg)
+	if err != nil {
+		return OutcomeFailed, fmt.Errorf("send invitation: %w", err)
+	}
+	if note != "" && !noteSent {
+		lg.Warn("no note dialog, request sent without note")
+		note = ""
+	}
+
+	outcome, status := OutcomeSent, StatusPending
+	if !waitForConfirmation(page, 5*time.Second) {
+		outcome, status = OutcomeFailed, ""
+	}
 
 	// Record connect
-	arr, _ := loadSent(cfg.StoragePath)
-	arr = append(arr, SentRequest{
+	cfg.record(SentRequest{
 		ProfileURL: profileURL,
+		Note:       note,
+		Outcome:    outcome,
+		Status:     status,
+		ApprovedBy: review.ReviewedBy,
 		Timestamp:  time.Now(),
 	})
-	if err := saveSent(cfg.StoragePath, arr); err != nil {
-		log.Printf("warning: could not save sent request: %v", err)
+
+	if outcome == OutcomeFailed {
+		return outcome, fmt.Errorf("connect request to %s not confirmed by page", profileURL)
+	}
+	if err := tok.Commit(); err != nil {
+		lg.Warn("could not record connect quota", "error", err)
+	}
+	if review.ID != "" && noteSent {
+		if err := approval.MarkSent("", review.ID); err != nil {
+			lg.Warn("could not mark approved note sent", "approval_id", review.ID, "error", err)
+		}
+	}
+
+	target := connectTarget(page, profileURL, vars)
+	if err := prospect.Update("", profileURL, func(p *prospect.Prospect) {
+		now := time.Now()
+		p.Name, p.Company, p.CampaignID = target.Name, target.Company, cfg.CampaignID
+		p.Status, p.Step, p.LastContactAt = prospect.StatusInvited, 0, &now
+	}); err != nil {
+		lg.Warn("could not update prospect", "error", err)
+	}
+
+	if cfg.FollowUpTemplateID != "" {
+		pm := PendingMessage{
+			ProfileURL: profileURL,
+			CampaignID: cfg.CampaignID,
+			TemplateID: cfg.FollowUpTemplateID,
+			Vars:       vars,
+			Step:       cfg.FollowUpStep,
+		}
+		if cfg.FollowUpDelay > 0 {
+			at := time.Now().Add(cfg.FollowUpDelay)
+			pm.NextAttemptAt = &at
+		}
+		added, err := EnqueuePending(cfg.PendingPath, pm)
+		if err != nil {
+			lg.Warn("could not enqueue follow-up", "template", cfg.FollowUpTemplateID, "error", err)
+		} else if added {
+			lg.Info("follow-up queued", "template", cfg.FollowUpTemplateID, logging.KeyStep, cfg.FollowUpStep)
+		}
 	}
 
-	return nil
+	return outcome, nil
 }
@@ -1,19 +1,58 @@
 package connect
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 )
 
 type PendingMessage struct {
+	ID         string            `json:"id,omitempty"`
 	ProfileURL string            `json:"profile_url"`
+	CampaignID string            `json:"campaign_id,omitempty"`
 	TemplateID string            `json:"template_id"`
 	Vars       map[string]string `json:"vars"`
-	CreatedAt  time.Time         `json:"created_at"`
+	// Step is the campaign sequence step this message sends (0 for
+	// entries queued before sequences existed)
+	Step      int       `json:"step,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Retry state maintained by the scheduler
+	Attempts      int        `json:"attempts,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
 }
 
+// Due reports whether the message may be attempted at now
+func (pm PendingMessage) Due(now time.Time) bool {
+	return pm.NextAttemptAt == nil || !now.Before(*pm.NextAttemptAt)
+}
+
+// UnmarshalJSON accepts the legacy "enqueued_at" field written by older
+// builds and maps it onto CreatedAt.
+func (pm *PendingMessage) UnmarshalJSON(b []byte) error {
+	type alias PendingMessage
+	aux := struct {
+		*alias
+		EnqueuedAt time.Time `json:"enqueued_at"`
+	}{alias: (*alias)(pm)}
+
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	if pm.CreatedAt.IsZero() {
+		pm.CreatedAt = aux.EnqueuedAt
+	}
+	return nil
+}
+
+var pendingMu sync.Mutex
+
 func LoadPending(path string) ([]PendingMessage, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return []PendingMessage{}, nil
@@ -29,9 +68,32 @@ func LoadPending(path string) ([]PendingMessage, error) {
 		return nil, err
 	}
 
+	// entries written before IDs existed get one derived from their
+	// content, so every load agrees on it until the next save persists it
+	for i := range arr {
+		if arr[i].ID == "" {
+			arr[i].ID = legacyPendingID(arr[i])
+		}
+	}
+
 	return arr, nil
 }
 
+func newPendingID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// legacyPendingID hashes the fields that identify a queued message
+func legacyPendingID(pm PendingMessage) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%s",
+		pm.ProfileURL, pm.TemplateID, pm.Step, pm.CreatedAt.UTC().Format(time.RFC3339Nano))))
+	return hex.EncodeToString(sum[:6])
+}
+
 func SavePending(path string, arr []PendingMessage) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
@@ -44,3 +106,57 @@ func SavePending(path string, arr []PendingMessage) error {
 
 	return os.WriteFile(path, b, 0o644)
 }
+
+// EnqueuePending appends pm to the pending queue unless the same template
+// is already queued for the profile. It reports whether pm was added.
+func EnqueuePending(path string, pm PendingMessage) (bool, error) {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+
+	arr, err := LoadPending(path)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range arr {
+		if p.ProfileURL == pm.ProfileURL && p.TemplateID == pm.TemplateID {
+			return false, nil
+		}
+	}
+
+	if pm.ID == "" {
+		pm.ID = newPendingID()
+	}
+	if pm.CreatedAt.IsZero() {
+		pm.CreatedAt = time.Now()
+	}
+	return true, SavePending(path, append(arr, pm))
+}
+
+// UpdatePending loads the queue, applies fn and saves the result while
+// holding the queue lock, so concurrent enqueues and removals are not lost.
+func UpdatePending(path string, fn func([]PendingMessage) []PendingMessage) error {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+
+	arr, err := LoadPending(path)
+	if err != nil {
+		return err
+	}
+	return SavePending(path, fn(arr))
+}
+
+// MigratePending rewrites the pending queue in the current schema,
+// converting legacy "enqueued_at" entries to "created_at" and assigning IDs.
+func MigratePending(path string) error {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	arr, err := LoadPending(path)
+	if err != nil {
+		return err
+	}
+	return SavePending(path, arr)
+}
@@ -0,0 +1,153 @@
+package connect
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/behavior"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/events"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
+)
+
+// ---------------- PAGE STATE ----------------
+
+// readConnectState returns the lowercased connect button and status texts
+func readConnectState(page *rod.Page) string {
+	res, err := page.Eval(`(btnSel, statusSel) => {
+		const btn = document.querySelector(btnSel);
+		const status = document.querySelector(statusSel);
+		return [btn ? btn.innerText : "", status ? status.innerText : ""].join(" | ");
+	}`, selectorConnectButton, selectorConnectStatus)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(res.Value.Str())
+}
+
+// classifyState maps the page state to an outcome. Company pages use
+// "following" in place of "connected".
+func classifyState(state string) (Outcome, bool) {
+	switch {
+	case strings.Contains(state, "pending"):
+		return OutcomeAlreadyPending, true
+	case strings.Contains(state, "connected"), strings.Contains(state, "following"):
+		return OutcomeAlreadyConnected, true
+	}
+	return "", false
+}
+
+// waitForConfirmation polls the page until the request shows as pending
+// (or followed, for companies), or the timeout expires.
+func waitForConfirmation(page *rod.Page, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) && page.GetContext().Err() == nil {
+		if _, ok := classifyState(readConnectState(page)); ok {
+			return true
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return false
+}
+
+// ---------------- STATUS CHECKS ----------------
+
+// CheckStatus opens the profile and reports the state of the connection:
+// StatusPending, StatusAccepted, or "" when no request is outstanding.
+func CheckStatus(ctx context.Context, page *rod.Page, profileURL string) (RequestStatus, error) {
+	page = page.Context(ctx)
+	if err := page.Navigate(profileURL); err != nil {
+		return "", err
+	}
+	if err := page.WaitLoad(); err != nil {
+		return "", err
+	}
+
+	behavior.ReadingPause()
+
+	outcome, ok := classifyState(readConnectState(page))
+	switch {
+	case !ok:
+		return "", nil
+	case outcome == OutcomeAlreadyConnected:
+		return StatusAccepted, nil
+	default:
+		return StatusPending, nil
+	}
+}
+
+// PendingRequests returns the latest sent request per profile that is still pending
+func PendingRequests(path string) ([]SentRequest, error) {
+	return StaleRequests(path, 0, time.Now())
+}
+
+// SentRequests returns every recorded connect request, oldest first
+func SentRequests(path string) ([]SentRequest, error) {
+	return loadSent(path)
+}
+
+// AcceptedProfiles returns the profiles whose latest request was accepted
+func AcceptedProfiles(path string) (map[string]bool, error) {
+	arr, err := loadSent(path)
+	if err != nil {
+		return nil, err
+	}
+	accepted := map[string]bool{}
+	for _, r := range arr {
+		if r.Status == StatusAccepted || r.Outcome == OutcomeAlreadyConnected {
+			accepted[r.ProfileURL] = true
+		}
+	}
+	return accepted, nil
+}
+
+// RefreshStatuses re-checks every pending request and records the ones
+// that were accepted. It returns the number of newly accepted requests.
+// lg receives the log lines (slog.Default() if nil).
+func RefreshStatuses(ctx context.Context, page *rod.Page, storagePath string, lg *slog.Logger) (int, error) {
+	if storagePath == "" {
+		storagePath = datadir.Path("sent_requests.json")
+	}
+
+	pending, err := PendingRequests(storagePath)
+	if err != nil {
+		return 0, err
+	}
+
+	lg = logging.Or(lg).With(logging.KeyAction, "status_check")
+	accepted := 0
+	for _, r := range pending {
+		if err := ctx.Err(); err != nil {
+			return accepted, err
+		}
+		ev := events.Start(events.TypeStatusCheck, r.ProfileURL, "", false)
+		status, err := CheckStatus(ctx, page, r.ProfileURL)
+		outcome := string(status)
+		if outcome == "" {
+			outcome = "none"
+		}
+		if err != nil {
+			outcome = events.OutcomeOf(err)
+		}
+		ev.Done(outcome, err)
+		if err != nil {
+			lg.Warn("could not check connection status", logging.KeyProspect, r.ProfileURL, "error", err)
+			continue
+		}
+		if status != StatusAccepted {
+			continue
+		}
+		if err := UpdateStatus(storagePath, r.ProfileURL, StatusAccepted); err != nil {
+			lg.Warn("could not update request status", logging.KeyProspect, r.ProfileURL, "error", err)
+			continue
+		}
+		lg.Info("connection accepted", logging.KeyProspect, r.ProfileURL)
+		accepted++
+
+		behavior.SleepHuman(800*time.Millisecond, 1500*time.Millisecond)
+	}
+	return accepted, nil
+}
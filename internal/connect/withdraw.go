@@ -0,0 +1,259 @@
+package connect
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/behavior"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dom"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dryrun"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/events"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
+)
+
+// DefaultWithdrawAfter is how long a request may stay pending before it is withdrawn
+const DefaultWithdrawAfter = 21 * 24 * time.Hour
+
+const selectorWithdrawButton = "#withdraw-btn"
+
+// WithdrawConfig controls the withdraw stage
+type WithdrawConfig struct {
+	MaxAge      time.Duration
+	StoragePath string
+	PendingPath string
+	// DryRun stops before the withdraw click and logs it instead
+	DryRun bool
+	// Logger receives the withdraw log lines (slog.Default() if nil)
+	Logger *slog.Logger
+}
+
+// ---------------- STORAGE ----------------
+
+// StaleRequests returns the latest sent request per profile that is still
+// pending and older than maxAge. Records written before outcomes were
+// tracked are treated as sent.
+func StaleRequests(path string, maxAge time.Duration, now time.Time) ([]SentRequest, error) {
+	arr, err := loadSent(path)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := map[string]SentRequest{}
+	order := []string{}
+	for _, r := range arr {
+		if r.Outcome != "" && r.Outcome != OutcomeSent {
+			continue
+		}
+		prev, ok := latest[r.ProfileURL]
+		if !ok {
+			order = append(order, r.ProfileURL)
+		}
+		if !ok || r.Timestamp.After(prev.Timestamp) {
+			latest[r.ProfileURL] = r
+		}
+	}
+
+	stale := []SentRequest{}
+	for _, u := range order {
+		r := latest[u]
+		if r.Status != "" && r.Status != StatusPending {
+			continue
+		}
+		if now.Sub(r.Timestamp) >= maxAge {
+			stale = append(stale, r)
+		}
+	}
+	return stale, nil
+}
+
+// UpdateStatus sets status on every sent record for profileURL
+func UpdateStatus(path, profileURL string, status RequestStatus) error {
+	now := time.Now()
+	return updateSent(path, func(arr []SentRequest) []SentRequest {
+		for i := range arr {
+			if arr[i].ProfileURL == profileURL && (arr[i].Outcome == "" || arr[i].Outcome == OutcomeSent) {
+				arr[i].Status = status
+				arr[i].UpdatedAt = &now
+			}
+		}
+		return arr
+	})
+}
+
+// RemovePending drops every queued follow-up for profileURL and returns how many were removed
+func RemovePending(path, profileURL string) (int, error) {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+
+	arr, err := LoadPending(path)
+	if err != nil {
+		return 0, err
+	}
+	kept := make([]PendingMessage, 0, len(arr))
+	for _, pm := range arr {
+		if pm.ProfileURL != profileURL {
+			kept = append(kept, pm)
+		}
+	}
+	removed := len(arr) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, SavePending(path, kept)
+}
+
+// ---------------- WITHDRAW ----------------
+
+// Withdraw opens the profile and withdraws a pending invitation.
+// It returns StatusAccepted if the request was accepted in the meantime,
+// and StatusWithdrawn once nothing is pending any more. lg receives the
+// log lines (slog.Default() if nil).
+func Withdraw(ctx context.Context, page *rod.Page, profileURL string, lg *slog.Logger) (RequestStatus, error) {
+	lg = logging.Or(lg).With(logging.KeyAction, "withdraw", logging.KeyProspect, profileURL)
+	return withdraw(ctx, page, profileURL, false, lg)
+}
+
+// withdraw is Withdraw; with dryRun it stops before the click and reports
+// the request as still pending
+func withdraw(ctx context.Context, page *rod.Page, profileURL string, dryRun bool, lg *slog.Logger) (RequestStatus, error) {
+	page = page.Context(ctx)
+	status, err := CheckStatus(ctx, page, profileURL)
+	if err != nil {
+		return "", err
+	}
+	switch status {
+	case "":
+		return StatusWithdrawn, nil
+	case StatusAccepted:
+		return StatusAccepted, nil
+	}
+
+	btn, err := dom.Find(page, selectorWithdrawButton, 5*time.Second)
+	if err != nil {
+		return "", fmt.Errorf("withdraw button on %s: %w", profileURL, err)
+	}
+	if dryRun {
+		return StatusPending, nil
+	}
+
+	behavior.ThinkPause()
+
+	if err := btn.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return "", err
+	}
+
+	if !waitForWithdraw(page, 5*time.Second) {
+		return "", fmt.Errorf("withdraw on %s not confirmed by page", profileURL)
+	}
+
+	lg.Info("invitation withdrawn")
+	return StatusWithdrawn, nil
+}
+
+func waitForWithdraw(page *rod.Page, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) && page.GetContext().Err() == nil {
+		if _, ok := classifyState(readConnectState(page)); !ok {
+			return true
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return false
+}
+
+func (cfg *WithdrawConfig) applyDefaults() {
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = DefaultWithdrawAfter
+	}
+	if cfg.StoragePath == "" {
+		cfg.StoragePath = datadir.Path("sent_requests.json")
+	}
+	if cfg.PendingPath == "" {
+		cfg.PendingPath = datadir.Path("pending_messages.json")
+	}
+}
+
+// WithdrawRequest withdraws the request to profileURL, records the new
+// status and, if it was withdrawn, drops its pending follow-ups.
+func WithdrawRequest(ctx context.Context, page *rod.Page, cfg WithdrawConfig, profileURL string) (status RequestStatus, err error) {
+	ev := events.Start(events.TypeWithdraw, profileURL, "", cfg.DryRun)
+	defer func() {
+		outcome := string(status)
+		if err != nil {
+			outcome = events.OutcomeOf(err)
+		}
+		ev.Done(outcome, err)
+	}()
+
+	cfg.applyDefaults()
+	lg := logging.Or(cfg.Logger).With(logging.KeyAction, "withdraw", logging.KeyProspect, profileURL)
+
+	status, err = withdraw(ctx, page, profileURL, cfg.DryRun, lg)
+	if err != nil {
+		return "", err
+	}
+
+	if cfg.DryRun {
+		if status == StatusPending {
+			lg.Info("dry run: would withdraw request")
+			if err := dryrun.Record(dryrun.Entry{Action: "withdraw", ProfileURL: profileURL}); err != nil {
+				lg.Warn("could not write dry-run log", "error", err)
+			}
+		}
+		return status, nil
+	}
+
+	if err := UpdateStatus(cfg.StoragePath, profileURL, status); err != nil {
+		lg.Warn("could not update request status", "error", err)
+	}
+
+	if status != StatusWithdrawn {
+		lg.Info("request was accepted, keeping follow-ups")
+		return status, nil
+	}
+
+	if n, err := RemovePending(cfg.PendingPath, profileURL); err != nil {
+		lg.Warn("could not drop pending follow-ups", "error", err)
+	} else if n > 0 {
+		lg.Info("dropped pending follow-ups", "count", n)
+	}
+	return status, nil
+}
+
+// WithdrawStale withdraws every request pending for longer than cfg.MaxAge,
+// updates the sent records and drops matching pending follow-ups.
+// It returns the number of requests withdrawn.
+func WithdrawStale(ctx context.Context, page *rod.Page, cfg WithdrawConfig) (int, error) {
+	cfg.applyDefaults()
+
+	stale, err := StaleRequests(cfg.StoragePath, cfg.MaxAge, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	lg := logging.Or(cfg.Logger).With(logging.KeyAction, "withdraw")
+	lg.Info("found stale requests", "count", len(stale), "max_age", cfg.MaxAge)
+
+	withdrawn := 0
+	for _, r := range stale {
+		if err := ctx.Err(); err != nil {
+			return withdrawn, err
+		}
+		status, err := WithdrawRequest(ctx, page, cfg, r.ProfileURL)
+		if err != nil {
+			lg.Warn("could not withdraw request", logging.KeyProspect, r.ProfileURL, "error", err)
+			continue
+		}
+		if status == StatusWithdrawn {
+			withdrawn++
+		}
+
+		behavior.SleepHuman(800*time.Millisecond, 1500*time.Millisecond)
+	}
+
+	return withdrawn, nil
+}
@@ -0,0 +1,324 @@
+package dashboard
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/connect"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dnc"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/events"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/prospect"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/ratelimit"
+)
+
+// DefaultAPIAddr keeps the API on the local machine
+const DefaultAPIAddr = "127.0.0.1:8092"
+
+// defaultEventLimit is how many events /events returns without ?limit
+const defaultEventLimit = 100
+
+// ErrNoToken is returned by APIHandler when no token is configured
+var ErrNoToken = errors.New("api token is required")
+
+// CampaignJSON is a campaign as returned by /campaigns
+type CampaignJSON struct {
+	ID              string                  `json:"id"`
+	Name            string                  `json:"name,omitempty"`
+	Paused          bool                    `json:"paused"`
+	PausedBy        string                  `json:"paused_by,omitempty"`
+	StateUpdatedAt  *time.Time              `json:"state_updated_at,omitempty"`
+	RequireApproval bool                    `json:"require_approval"`
+	Steps           int                     `json:"steps"`
+	Prospects       map[prospect.Status]int `json:"prospects"`
+	Queued          int                     `json:"queued"`
+}
+
+// QueueJSON is a pending message as returned by /queue
+type QueueJSON struct {
+	connect.PendingMessage
+	PlannedAt   time.Time `json:"planned_at"`
+	WindowError string    `json:"window_error,omitempty"`
+}
+
+// QuotaJSON is one limit's usage as returned by /quotas. Limit is nil
+// for recorded actions without a configured limit.
+type QuotaJSON struct {
+	Action      string           `json:"action"`
+	Scope       ratelimit.Scope  `json:"scope"`
+	Limit       *ratelimit.Limit `json:"limit"`
+	Used        int              `json:"used"`
+	Reserved    int              `json:"reserved"`
+	Remaining   int              `json:"remaining"`
+	WindowStart time.Time        `json:"window_start"`
+	ResetAt     *time.Time       `json:"reset_at,omitempty"`
+}
+
+// EventJSON is one event log or audit log entry as returned by /events;
+// Data is the entry as written to its log
+type EventJSON struct {
+	Time time.Time       `json:"time"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// APIHandler serves the read-only JSON API. Every request must carry
+// "Authorization: Bearer <token>".
+func APIHandler(cfg Config, token string) (http.Handler, error) {
+	if token == "" {
+		return nil, ErrNoToken
+	}
+	lg := logging.Or(cfg.Logger).With(logging.KeyAction, "api")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/campaigns", get(lg, cfg.apiCampaigns))
+	mux.HandleFunc("/prospects", get(lg, cfg.apiProspects))
+	mux.HandleFunc("/queue", get(lg, cfg.apiQueue))
+	mux.HandleFunc("/quotas", get(lg, cfg.apiQuotas))
+	mux.HandleFunc("/events", get(lg, cfg.apiEvents))
+	return requireToken(lg, token, mux), nil
+}
+
+func requireToken(lg *slog.Logger, token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="automation"`)
+			writeJSON(lg, w, http.StatusUnauthorized, map[string]string{"error": "missing or invalid token"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// get adapts a read function to a GET-only JSON endpoint
+func get(lg *slog.Logger, fn func(r *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(lg, w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		v, err := fn(r)
+		if err != nil {
+			var bad badRequest
+			if errors.As(err, &bad) {
+				writeJSON(lg, w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+			lg.Error("api request failed", "path", r.URL.Path, "error", err)
+			writeJSON(lg, w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(lg, w, http.StatusOK, v)
+	}
+}
+
+// badRequest marks an error in the request's query parameters
+type badRequest string
+
+func (e badRequest) Error() string { return string(e) }
+
+func writeJSON(lg *slog.Logger, w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		lg.Warn("could not write response", "error", err)
+	}
+}
+
+func (cfg Config) apiCampaigns(r *http.Request) (any, error) {
+	views, err := cfg.Campaigns()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CampaignJSON, len(views))
+	for i, v := range views {
+		c := CampaignJSON{
+			ID:              v.Campaign.ID,
+			Name:            v.Campaign.Name,
+			Paused:          v.State.Paused,
+			PausedBy:        v.State.By,
+			RequireApproval: v.Campaign.RequireApproval,
+			Steps:           len(v.Campaign.Steps()),
+			Prospects:       v.Prospects,
+			Queued:          v.Queued,
+		}
+		if !v.State.UpdatedAt.IsZero() {
+			at := v.State.UpdatedAt
+			c.StateUpdatedAt = &at
+		}
+		out[i] = c
+	}
+	return out, nil
+}
+
+// apiProspects supports ?campaign= and ?status=
+func (cfg Config) apiProspects(r *http.Request) (any, error) {
+	arr, err := cfg.Prospects()
+	if err != nil {
+		return nil, err
+	}
+	campaignID, status := r.URL.Query().Get("campaign"), prospect.Status(r.URL.Query().Get("status"))
+	out := []prospect.Prospect{}
+	for _, p := range arr {
+		if (campaignID == "" || p.CampaignID == campaignID) && (status == "" || p.Status == status) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// apiQueue supports ?campaign=
+func (cfg Config) apiQueue(r *http.Request) (any, error) {
+	plan, err := cfg.Queue(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	campaignID := r.URL.Query().Get("campaign")
+	out := []QueueJSON{}
+	for _, p := range plan {
+		if campaignID != "" && p.Message.CampaignID != campaignID {
+			continue
+		}
+		q := QueueJSON{PendingMessage: p.Message, PlannedAt: p.PlannedAt}
+		if p.WindowErr != nil {
+			q.WindowError = p.WindowErr.Error()
+		}
+		out = append(out, q)
+	}
+	return out, nil
+}
+
+func (cfg Config) apiQuotas(r *http.Request) (any, error) {
+	usage, err := cfg.Quotas(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]QuotaJSON, len(usage))
+	for i, u := range usage {
+		q := QuotaJSON{
+			Action:      u.Action,
+			Scope:       u.Scope,
+			Limit:       u.Limit,
+			Used:        u.Used,
+			Reserved:    u.Reserved,
+			Remaining:   u.Remaining(),
+			WindowStart: u.WindowStart,
+		}
+		if !u.ResetAt.IsZero() {
+			at := u.ResetAt
+			q.ResetAt = &at
+		}
+		out[i] = q
+	}
+	return out, nil
+}
+
+// apiEvents supports ?since= (RFC 3339), ?type= and ?limit= (default 100)
+func (cfg Config) apiEvents(r *http.Request) (any, error) {
+	q := r.URL.Query()
+	var since time.Time
+	if s := q.Get("since"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, badRequest("since: expected an RFC 3339 time")
+		}
+		since = t
+	}
+	limit := defaultEventLimit
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			return nil, badRequest("limit: expected a positive number")
+		}
+		limit = n
+	}
+
+	all, err := cfg.Events()
+	if err != nil {
+		return nil, err
+	}
+	out := []EventJSON{}
+	for _, e := range all {
+		if e.Time.Before(since) || (q.Get("type") != "" && e.Type != q.Get("type")) {
+			continue
+		}
+		out = append(out, e)
+	}
+	// newest last, like the logs; keep the most recent limit entries
+	if len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out, nil
+}
+
+// Events returns the action event log and the quota reset and
+// do-not-contact audit logs merged into one list, oldest first
+func (cfg Config) Events() ([]EventJSON, error) {
+	actions, err := readEvents(events.Path(), func(raw json.RawMessage) string {
+		var e struct {
+			Type string `json:"type"`
+		}
+		_ = json.Unmarshal(raw, &e)
+		return e.Type
+	})
+	if err != nil {
+		return nil, err
+	}
+	quota, err := readEvents(ratelimit.AuditPath(), func(json.RawMessage) string { return "quota_reset" })
+	if err != nil {
+		return nil, err
+	}
+	list, err := readEvents(dnc.AuditPath(""), func(raw json.RawMessage) string {
+		var a struct {
+			Op string `json:"op"`
+		}
+		_ = json.Unmarshal(raw, &a)
+		return "dnc_" + a.Op
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := append(append(actions, quota...), list...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, nil
+}
+
+// readEvents reads a JSONL audit log whose entries have a "time" field.
+// A missing log is empty; unreadable lines are skipped.
+func readEvents(path string, typeOf func(json.RawMessage) string) ([]EventJSON, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []EventJSON
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Bytes()
+		var head struct {
+			Time time.Time `json:"time"`
+		}
+		if len(line) == 0 || json.Unmarshal(line, &head) != nil {
+			continue
+		}
+		raw := json.RawMessage(append([]byte(nil), line...))
+		out = append(out, EventJSON{Time: head.Time, Type: typeOf(raw), Data: raw})
+	}
+	return out, sc.Err()
+}
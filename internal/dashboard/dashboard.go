@@ -0,0 +1,178 @@
+package dashboard
+
+import (
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/campaign"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/connect"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/prospect"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/ratelimit"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/scheduler"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/webguard"
+)
+
+// DefaultAddr keeps the dashboard on the local machine
+const DefaultAddr = "127.0.0.1:8091"
+
+// maxErrors is how many recent errors are shown
+const maxErrors = 25
+
+// Config tells the dashboard where to read from
+type Config struct {
+	// CampaignsPath is the campaign configuration (data/campaigns.json if empty)
+	CampaignsPath string
+	// QuotaChains returns the configured limits per action; quota usage is
+	// only shown for recorded actions when nil
+	QuotaChains func() map[string][]ratelimit.ScopeLimits
+	// Operator is recorded with pause and resume actions
+	Operator string
+	// Guard protects the dashboard page; the JSON API checks its own token
+	Guard webguard.Guard
+	// Logger receives the dashboard and API log lines (slog.Default() if nil)
+	Logger *slog.Logger
+}
+
+// CampaignView is a campaign with its run state and progress
+type CampaignView struct {
+	Campaign campaign.Campaign
+	State    campaign.State
+	// Prospects counts the campaign's prospects by status
+	Prospects map[prospect.Status]int
+	Queued    int
+}
+
+// ErrorView is one recent failure
+type ErrorView struct {
+	At         time.Time
+	Source     string
+	ProfileURL string
+	CampaignID string
+	Error      string
+	// NextAttemptAt is set for messages that will be retried
+	NextAttemptAt *time.Time
+}
+
+// Campaigns returns every configured campaign with its state, prospect
+// counts and queued messages
+func (cfg Config) Campaigns() ([]CampaignView, error) {
+	camps, err := campaign.LoadCampaigns(cfg.CampaignsPath)
+	if err != nil {
+		return nil, err
+	}
+	states, err := campaign.LoadStates("")
+	if err != nil {
+		return nil, err
+	}
+	prospects, err := prospect.Load("")
+	if err != nil {
+		return nil, err
+	}
+	pend, err := connect.LoadPending(datadir.Path("pending_messages.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]CampaignView, len(camps))
+	for i, c := range camps {
+		v := CampaignView{Campaign: c, State: states[c.ID], Prospects: map[prospect.Status]int{}}
+		for _, p := range prospects {
+			if p.CampaignID == c.ID {
+				v.Prospects[p.Status]++
+			}
+		}
+		for _, pm := range pend {
+			if pm.CampaignID == c.ID {
+				v.Queued++
+			}
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// Prospects returns every prospect, most recently updated first
+func (cfg Config) Prospects() ([]prospect.Prospect, error) {
+	arr, err := prospect.Load("")
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(arr, func(i, j int) bool { return arr[i].UpdatedAt.After(arr[j].UpdatedAt) })
+	return arr, nil
+}
+
+// Queue returns the pending messages ordered by planned send time
+func (cfg Config) Queue(now time.Time) ([]scheduler.PlannedMessage, error) {
+	return scheduler.Plan(scheduler.SchedulerConfig{CampaignsPath: cfg.CampaignsPath}, now)
+}
+
+// Quotas returns the usage of every configured and recorded quota
+func (cfg Config) Quotas(now time.Time) ([]ratelimit.Usage, error) {
+	var chains map[string][]ratelimit.ScopeLimits
+	if cfg.QuotaChains != nil {
+		chains = cfg.QuotaChains()
+	}
+	return ratelimit.Status(chains, datadir.Path("quotas.json"), now)
+}
+
+// Errors returns the most recent failures: dead letters, messages waiting
+// for a retry and unconfirmed connect requests, newest first
+func (cfg Config) Errors() ([]ErrorView, error) {
+	var out []ErrorView
+
+	dead, err := scheduler.LoadDeadLetters("")
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dead {
+		out = append(out, ErrorView{
+			At:         d.FailedAt,
+			Source:     "dead letter",
+			ProfileURL: d.Message.ProfileURL,
+			CampaignID: d.Message.CampaignID,
+			Error:      d.Message.LastError,
+		})
+	}
+
+	pend, err := connect.LoadPending(datadir.Path("pending_messages.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, pm := range pend {
+		if pm.LastError == "" {
+			continue
+		}
+		out = append(out, ErrorView{
+			At:            pm.CreatedAt,
+			Source:        "message retry",
+			ProfileURL:    pm.ProfileURL,
+			CampaignID:    pm.CampaignID,
+			Error:         pm.LastError,
+			NextAttemptAt: pm.NextAttemptAt,
+		})
+	}
+
+	sent, err := connect.SentRequests(datadir.Path("sent_requests.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range sent {
+		if r.Outcome != connect.OutcomeFailed && r.Outcome != connect.OutcomeButtonMissing {
+			continue
+		}
+		out = append(out, ErrorView{
+			At:         r.Timestamp,
+			Source:     "connect",
+			ProfileURL: r.ProfileURL,
+			Error:      string(r.Outcome),
+		})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].At.After(out[j].At) })
+	if len(out) > maxErrors {
+		out = out[:maxErrors]
+	}
+	return out, nil
+}
@@ -0,0 +1,235 @@
+package dashboard
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/campaign"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/prospect"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/ratelimit"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/scheduler"
+)
+
+// maxProspects is how many recently updated prospects are listed
+const maxProspects = 100
+
+var funcs = template.FuncMap{
+	// when formats a time or *time.Time, "-" if unset
+	"when": func(v any) string {
+		var t time.Time
+		switch x := v.(type) {
+		case time.Time:
+			t = x
+		case *time.Time:
+			if x != nil {
+				t = *x
+			}
+		}
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Local().Format("Mon 2006-01-02 15:04")
+	},
+}
+
+var page = template.Must(template.New("dashboard").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="30">
+<title>Outreach dashboard</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", sans-serif; background: #f5f7fa; color: #333; margin: 0; padding: 24px; }
+  h1 { color: #0f3460; font-size: 22px; }
+  h2 { color: #0f3460; font-size: 17px; margin-top: 28px; }
+  table { border-collapse: collapse; background: white; border: 1px solid #e8ecf1; width: 100%; font-size: 13px; }
+  th, td { text-align: left; padding: 6px 10px; border-bottom: 1px solid #e8ecf1; vertical-align: top; }
+  th { background: #eef2f7; }
+  .paused { color: #b35c00; font-weight: 600; }
+  .full { color: #c0392b; font-weight: 600; }
+  .err { color: #c0392b; }
+  .muted { color: #888; }
+  button { font: inherit; padding: 4px 12px; border-radius: 6px; border: 1px solid #0f3460; background: white; cursor: pointer; }
+  .flash { background: #d4edda; border: 1px solid #c3e6cb; padding: 10px; border-radius: 6px; }
+  .error { background: #f8d7da; border-color: #f5c6cb; }
+</style>
+</head>
+<body>
+<h1>Outreach dashboard</h1>
+<p class="muted">Updated {{when .Now}} · refreshes every 30s</p>
+{{if .Flash}}<p class="flash{{if .Error}} error{{end}}">{{.Flash}}</p>{{end}}
+{{range .Problems}}<p class="flash error">{{.}}</p>{{end}}
+
+<h2>Campaigns</h2>
+<table>
+<tr><th>Campaign</th><th>State</th><th>Prospects</th><th>Queued</th><th></th></tr>
+{{range .Campaigns}}
+<tr>
+  <td>{{.Campaign.ID}}{{if .Campaign.Name}} · {{.Campaign.Name}}{{end}}{{if .Campaign.RequireApproval}} <span class="muted">(approval)</span>{{end}}</td>
+  <td>{{if .State.Paused}}<span class="paused">paused</span>{{else}}running{{end}}{{if .State.By}} <span class="muted">by {{.State.By}} {{when .State.UpdatedAt}}</span>{{end}}</td>
+  <td>{{range $status, $n := .Prospects}}{{$status}} {{$n}}<br>{{else}}-{{end}}</td>
+  <td>{{.Queued}}</td>
+  <td>
+    <form method="post" action="/campaigns/state">
+      <input type="hidden" name="id" value="{{.Campaign.ID}}">
+      {{if .State.Paused}}<button name="action" value="resume">Resume</button>{{else}}<button name="action" value="pause">Pause</button>{{end}}
+    </form>
+  </td>
+</tr>
+{{else}}
+<tr><td colspan="5">No campaigns configured.</td></tr>
+{{end}}
+</table>
+
+<h2>Pending messages ({{len .Queue}})</h2>
+<table>
+<tr><th>Profile</th><th>Campaign</th><th>Template</th><th>Step</th><th>Attempts</th><th>Next attempt</th><th>Last error</th></tr>
+{{range .Queue}}
+<tr>
+  <td>{{.Message.ProfileURL}}</td>
+  <td>{{.Message.CampaignID}}</td>
+  <td>{{.Message.TemplateID}}</td>
+  <td>{{.Message.Step}}</td>
+  <td>{{.Message.Attempts}}</td>
+  <td>{{when .PlannedAt}}{{if .WindowErr}} <span class="err">{{.WindowErr}}</span>{{end}}</td>
+  <td class="err">{{.Message.LastError}}</td>
+</tr>
+{{else}}
+<tr><td colspan="7">Queue is empty.</td></tr>
+{{end}}
+</table>
+
+<h2>Quota usage</h2>
+<table>
+<tr><th>Action</th><th>Scope</th><th>Used</th><th>Limit</th><th>Resets</th></tr>
+{{range .Quotas}}
+<tr>
+  <td>{{.Action}}</td>
+  <td>{{.Scope}}</td>
+  <td{{if and .Limit (eq .Remaining 0)}} class="full"{{end}}>{{.Used}}{{if .Reserved}} + {{.Reserved}} reserved{{end}}</td>
+  <td>{{if .Limit}}{{.Limit}}{{else}}<span class="muted">none</span>{{end}}</td>
+  <td>{{when .ResetAt}}</td>
+</tr>
+{{else}}
+<tr><td colspan="5">No quotas configured or recorded.</td></tr>
+{{end}}
+</table>
+
+<h2>Recent errors</h2>
+<table>
+<tr><th>When</th><th>Source</th><th>Profile</th><th>Campaign</th><th>Error</th></tr>
+{{range .Errors}}
+<tr>
+  <td>{{when .At}}</td>
+  <td>{{.Source}}{{if .NextAttemptAt}} <span class="muted">(retry {{when .NextAttemptAt}})</span>{{end}}</td>
+  <td>{{.ProfileURL}}</td>
+  <td>{{.CampaignID}}</td>
+  <td class="err">{{.Error}}</td>
+</tr>
+{{else}}
+<tr><td colspan="5">No errors.</td></tr>
+{{end}}
+</table>
+
+<h2>Prospects ({{.ProspectCount}}{{if gt .ProspectCount (len .Prospects)}}, latest {{len .Prospects}}{{end}})</h2>
+<table>
+<tr><th>Profile</th><th>Name</th><th>Company</th><th>Campaign</th><th>Status</th><th>Step</th><th>Last contact</th><th>Updated</th></tr>
+{{range .Prospects}}
+<tr>
+  <td>{{.ProfileURL}}</td>
+  <td>{{.Name}}</td>
+  <td>{{.Company}}</td>
+  <td>{{.CampaignID}}</td>
+  <td>{{.Status}}{{if .SkipReason}} <span class="muted">({{.SkipReason}})</span>{{end}}</td>
+  <td>{{.Step}}</td>
+  <td>{{when .LastContactAt}}</td>
+  <td>{{when .UpdatedAt}}</td>
+</tr>
+{{else}}
+<tr><td colspan="8">No prospects yet.</td></tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
+
+type pageData struct {
+	Now           time.Time
+	Flash         string
+	Error         bool
+	Problems      []string
+	Campaigns     []CampaignView
+	Queue         []scheduler.PlannedMessage
+	Quotas        []ratelimit.Usage
+	Errors        []ErrorView
+	Prospects     []prospect.Prospect
+	ProspectCount int
+}
+
+// Handler serves the dashboard. Campaigns are paused and resumed by
+// posting to /campaigns/state.
+func Handler(cfg Config) http.Handler {
+	lg := logging.Or(cfg.Logger).With(logging.KeyAction, "dashboard")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		render(w, cfg, r.URL.Query().Get("flash"), r.URL.Query().Get("error") != "")
+	})
+	mux.HandleFunc("/campaigns/state", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		id, action := r.FormValue("id"), r.FormValue("action")
+		if id == "" || (action != "pause" && action != "resume") {
+			http.Error(w, "id and action (pause or resume) are required", http.StatusBadRequest)
+			return
+		}
+		if err := campaign.SetPaused("", id, action == "pause", cfg.Operator); err != nil {
+			lg.Warn("could not "+action+" campaign", logging.KeyCampaign, id, "error", err)
+			http.Redirect(w, r, "/?error=1&flash="+template.URLQueryEscaper(err.Error()), http.StatusSeeOther)
+			return
+		}
+		lg.Info("campaign "+action+"d from the dashboard", logging.KeyCampaign, id, "by", cfg.Operator)
+		http.Redirect(w, r, "/?flash="+template.URLQueryEscaper("campaign "+id+" "+action+"d"), http.StatusSeeOther)
+	})
+	return cfg.Guard.Wrap(mux)
+}
+
+// render collects every section; a section that fails to load is reported
+// on the page instead of failing the whole dashboard
+func render(w http.ResponseWriter, cfg Config, flash string, isErr bool) {
+	now := time.Now()
+	data := pageData{Now: now, Flash: flash, Error: isErr}
+	problem := func(section string, err error) {
+		if err != nil {
+			data.Problems = append(data.Problems, section+": "+err.Error())
+		}
+	}
+
+	var err error
+	data.Campaigns, err = cfg.Campaigns()
+	problem("campaigns", err)
+	data.Queue, err = cfg.Queue(now)
+	problem("pending messages", err)
+	data.Quotas, err = cfg.Quotas(now)
+	problem("quotas", err)
+	data.Errors, err = cfg.Errors()
+	problem("errors", err)
+	data.Prospects, err = cfg.Prospects()
+	problem("prospects", err)
+	data.ProspectCount = len(data.Prospects)
+	if len(data.Prospects) > maxProspects {
+		data.Prospects = data.Prospects[:maxProspects]
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, data); err != nil {
+		logging.Or(cfg.Logger).Warn("could not render page", logging.KeyAction, "dashboard", "error", err)
+	}
+}
@@ -0,0 +1,13 @@
+package datadir
+
+import "path/filepath"
+
+// Dir holds run state: sent records, queues, quotas and logs. Sandbox mode
+// points it at a separate directory. Configuration (campaigns, templates,
+// limits) always stays in data/.
+var Dir = "data"
+
+// Path returns the path of a state file in Dir
+func Path(name string) string {
+	return filepath.Join(Dir, name)
+}
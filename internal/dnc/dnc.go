@@ -0,0 +1,367 @@
+package dnc
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/prospect"
+)
+
+// Kind selects what an entry is matched against
+type Kind string
+
+const (
+	// KindProfile matches the profile id (the id= parameter or last path
+	// segment of the profile URL)
+	KindProfile Kind = "profile"
+	// KindCompany matches the prospect's company, case-insensitively
+	KindCompany Kind = "company"
+	// KindName matches the prospect's name against a glob pattern such as
+	// "*wilson" (case-insensitive)
+	KindName Kind = "name"
+)
+
+// Entry is one do-not-contact rule
+type Entry struct {
+	Kind    Kind      `json:"kind"`
+	Value   string    `json:"value"`
+	Reason  string    `json:"reason,omitempty"`
+	Source  string    `json:"source,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+func (e Entry) String() string {
+	s := fmt.Sprintf("%s %q", e.Kind, e.Value)
+	if e.Reason != "" {
+		s += " (" + e.Reason + ")"
+	}
+	return s
+}
+
+// DefaultPath is the do-not-contact list. Like campaigns and templates it
+// stays in data/ in sandbox mode, so opt-outs always apply.
+var DefaultPath = "data/dnc.json"
+
+// Target is the prospect an action is about to touch. Empty fields are
+// not matched.
+type Target struct {
+	ProfileURL string
+	Name       string
+	Company    string
+}
+
+// ErrBlocked is returned when a target is on the do-not-contact list
+var ErrBlocked = errors.New("on do-not-contact list")
+
+// BlockedError names the target and the entry it matched.
+// errors.Is(err, ErrBlocked) matches it.
+type BlockedError struct {
+	Action string
+	Target Target
+	Entry  Entry
+}
+
+func (e *BlockedError) Error() string {
+	who := e.Target.ProfileURL
+	if who == "" {
+		who = e.Target.Name
+	}
+	return fmt.Sprintf("%s to %s refused: do-not-contact %s", e.Action, who, e.Entry)
+}
+
+// Is makes BlockedError match ErrBlocked
+func (e *BlockedError) Is(target error) bool { return target == ErrBlocked }
+
+var mu sync.Mutex
+
+// Load reads the do-not-contact list; a missing file is an empty list
+func Load(p string) ([]Entry, error) {
+	if p == "" {
+		p = DefaultPath
+	}
+	b, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var arr []Entry
+	if err := json.Unmarshal(b, &arr); err != nil {
+		return nil, err
+	}
+	return arr, nil
+}
+
+func save(p string, arr []Entry) error {
+	if p == "" {
+		p = DefaultPath
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(arr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, b, 0o644)
+}
+
+// ProfileID returns the id of a profile URL: its id= parameter, or else
+// its last path segment
+func ProfileID(profileURL string) string {
+	u, err := url.Parse(profileURL)
+	if err != nil {
+		return ""
+	}
+	if id := u.Query().Get("id"); id != "" {
+		return id
+	}
+	return path.Base(strings.TrimSuffix(u.Path, "/"))
+}
+
+// Matches reports whether e applies to t
+func (e Entry) Matches(t Target) bool {
+	v := strings.ToLower(strings.TrimSpace(e.Value))
+	if v == "" {
+		return false
+	}
+	switch e.Kind {
+	case KindProfile:
+		return t.ProfileURL != "" && (strings.EqualFold(ProfileID(t.ProfileURL), v) || strings.EqualFold(t.ProfileURL, v))
+	case KindCompany:
+		return t.Company != "" && strings.EqualFold(strings.TrimSpace(t.Company), v)
+	case KindName:
+		if t.Name == "" {
+			return false
+		}
+		ok, err := path.Match(v, strings.ToLower(strings.TrimSpace(t.Name)))
+		return err == nil && ok
+	}
+	return false
+}
+
+// Match returns the first entry of list that applies to t
+func Match(list []Entry, t Target) (Entry, bool) {
+	for _, e := range list {
+		if e.Matches(t) {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Check returns a *BlockedError if t is on the list at DefaultPath
+func Check(action string, t Target) error {
+	list, err := Load("")
+	if err != nil {
+		return fmt.Errorf("load do-not-contact list: %w", err)
+	}
+	if e, ok := Match(list, t); ok {
+		return &BlockedError{Action: action, Target: t, Entry: e}
+	}
+	return nil
+}
+
+// Guard runs Check before action and, if t is blocked, writes the skip
+// reason to the prospect record (unless dryRun). Errors loading the list
+// block the action too, so a broken file never lets opt-outs through.
+// lg should carry the caller's action and prospect fields; if nil the
+// default logger is used with both added.
+func Guard(action string, t Target, dryRun bool, lg *slog.Logger) error {
+	err := Check(action, t)
+	if err == nil {
+		return nil
+	}
+	if lg == nil {
+		lg = slog.Default().With(logging.KeyAction, action, logging.KeyProspect, t.ProfileURL)
+	}
+	lg.Info("skipping: do-not-contact", "error", err)
+	if dryRun || t.ProfileURL == "" {
+		return err
+	}
+	var be *BlockedError
+	if errors.As(err, &be) {
+		if perr := prospect.MarkSkipped("", t.ProfileURL, "do-not-contact: "+be.Entry.String()); perr != nil {
+			lg.Warn("could not record skip", "error", perr)
+		}
+	}
+	return err
+}
+
+/*
+========================
+List changes (audited)
+========================
+*/
+
+// Audit is one change to the list
+type Audit struct {
+	Time  time.Time `json:"time"`
+	Op    string    `json:"op"`
+	Entry Entry     `json:"entry"`
+	By    string    `json:"by,omitempty"`
+}
+
+// AuditPath returns the audit log kept next to the list at p
+func AuditPath(p string) string {
+	if p == "" {
+		p = DefaultPath
+	}
+	return filepath.Join(filepath.Dir(p), "dnc_audit.jsonl")
+}
+
+func appendAudit(listPath string, entries []Audit) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	p := AuditPath(listPath)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, a := range entries {
+		b, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(append(b, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validKind(k Kind) bool {
+	return k == KindProfile || k == KindCompany || k == KindName
+}
+
+// Add puts entries on the list at p, skipping exact duplicates, and
+// audits each addition. It returns how many were added.
+func Add(p, by string, entries ...Entry) (int, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	list, err := Load(p)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	var audits []Audit
+	for _, e := range entries {
+		e.Kind = Kind(strings.ToLower(strings.TrimSpace(string(e.Kind))))
+		e.Value = strings.TrimSpace(e.Value)
+		if !validKind(e.Kind) {
+			return 0, fmt.Errorf("do-not-contact entry %q: unknown kind %q (expected profile, company or name)", e.Value, e.Kind)
+		}
+		if e.Value == "" {
+			return 0, fmt.Errorf("do-not-contact %s entry: empty value", e.Kind)
+		}
+		if _, err := path.Match(strings.ToLower(e.Value), ""); e.Kind == KindName && err != nil {
+			return 0, fmt.Errorf("do-not-contact name pattern %q: %w", e.Value, err)
+		}
+		if contains(list, e) {
+			continue
+		}
+		if e.AddedAt.IsZero() {
+			e.AddedAt = now
+		}
+		list = append(list, e)
+		audits = append(audits, Audit{Time: now, Op: "add", Entry: e, By: by})
+	}
+	if len(audits) == 0 {
+		return 0, nil
+	}
+	if err := save(p, list); err != nil {
+		return 0, err
+	}
+	return len(audits), appendAudit(p, audits)
+}
+
+// Remove deletes the entry of kind and value from the list at p and
+// audits it. It returns false if there was no such entry.
+func Remove(p, by string, kind Kind, value string) (bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	list, err := Load(p)
+	if err != nil {
+		return false, err
+	}
+	kept := list[:0]
+	var audits []Audit
+	for _, e := range list {
+		if e.Kind == kind && strings.EqualFold(e.Value, value) {
+			audits = append(audits, Audit{Time: time.Now(), Op: "remove", Entry: e, By: by})
+			continue
+		}
+		kept = append(kept, e)
+	}
+	if len(audits) == 0 {
+		return false, nil
+	}
+	if err := save(p, kept); err != nil {
+		return false, err
+	}
+	return true, appendAudit(p, audits)
+}
+
+func contains(list []Entry, e Entry) bool {
+	for _, x := range list {
+		if x.Kind == e.Kind && strings.EqualFold(x.Value, e.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportCSV adds the rows of r to the list at p. Each row is
+// kind,value[,reason]; a header row starting with "kind" is skipped.
+// source is stored with each entry (e.g. the file name).
+func ImportCSV(p, by, source string, r io.Reader) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var entries []Entry
+	for line := 1; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("csv line %d: %w", line, err)
+		}
+		if len(row) == 0 || (len(row) == 1 && strings.TrimSpace(row[0]) == "") {
+			continue
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(row[0]), "kind") {
+			continue
+		}
+		if len(row) < 2 {
+			return 0, fmt.Errorf("csv line %d: expected kind,value[,reason]", line)
+		}
+		e := Entry{Kind: Kind(row[0]), Value: row[1], Source: source}
+		if len(row) > 2 {
+			e.Reason = strings.TrimSpace(row[2])
+		}
+		entries = append(entries, e)
+	}
+	return Add(p, by, entries...)
+}
@@ -0,0 +1,62 @@
+package dom
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// ErrElementNotFound is returned when a selector matches nothing in time
+var ErrElementNotFound = errors.New("element not found")
+
+// DefaultTimeout is how long Find waits when no timeout is given
+const DefaultTimeout = 5 * time.Second
+
+// Find waits up to timeout for selector on page. A missing element is
+// reported as ErrElementNotFound; cancellation of the page's own context
+// is returned as is, so callers can tell the two apart.
+func Find(page *rod.Page, selector string, timeout time.Duration) (*rod.Element, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	el, err := page.Timeout(timeout).Element(selector)
+	if err != nil {
+		if ctxErr := page.GetContext().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("%s: %w", selector, ErrElementNotFound)
+	}
+	return el.CancelTimeout(), nil
+}
+
+// FindIn is Find scoped to the children of parent
+func FindIn(parent *rod.Element, selector string, timeout time.Duration) (*rod.Element, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	el, err := parent.Timeout(timeout).Element(selector)
+	if err != nil {
+		if ctxErr := parent.GetContext().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("%s: %w", selector, ErrElementNotFound)
+	}
+	return el.CancelTimeout(), nil
+}
+
+// Text returns the trimmed text of the first element matching selector,
+// or "" if there is none. It does not wait for the element to appear.
+func Text(page *rod.Page, selector string) string {
+	has, el, err := page.Has(selector)
+	if err != nil || !has {
+		return ""
+	}
+	txt, err := el.Text()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(txt)
+}
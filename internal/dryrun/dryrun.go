@@ -0,0 +1,54 @@
+package dryrun
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+)
+
+// Entry is one action that dry-run mode stopped short of performing
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Action     string    `json:"action"`
+	ProfileURL string    `json:"profile_url,omitempty"`
+	CampaignID string    `json:"campaign_id,omitempty"`
+	// Detail is the note, message or comment that would have been sent
+	Detail string `json:"detail,omitempty"`
+}
+
+var mu sync.Mutex
+
+// Path returns the dry-run log file
+func Path() string {
+	return datadir.Path("dry_run.jsonl")
+}
+
+// Record appends e to the dry-run log, one JSON object per line
+func Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,175 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dnc"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/ratelimit"
+)
+
+// Type is the kind of action an event records
+type Type string
+
+const (
+	TypeLogin       Type = "login"
+	TypeConnect     Type = "connect"
+	TypeMessage     Type = "message"
+	TypeWithdraw    Type = "withdraw"
+	TypeStatusCheck Type = "status_check"
+	TypeLike        Type = "like"
+	TypeComment     Type = "comment"
+)
+
+// Outcomes shared by every action type; packages add their own
+// (e.g. connect's "already_pending")
+const (
+	OutcomeOK       = "ok"
+	OutcomeFailed   = "failed"
+	OutcomeBlocked  = "blocked"
+	OutcomeQuota    = "quota_exceeded"
+	OutcomeCanceled = "canceled"
+	OutcomeTimeout  = "timeout"
+)
+
+// Event is one action attempt
+type Event struct {
+	Time       time.Time `json:"time"`
+	Type       Type      `json:"type"`
+	ProfileURL string    `json:"profile_url,omitempty"`
+	CampaignID string    `json:"campaign_id,omitempty"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	// Detail identifies targets that are not profiles, such as a post
+	Detail string `json:"detail,omitempty"`
+}
+
+var mu sync.Mutex
+
+// Path returns the event log file
+func Path() string {
+	return datadir.Path("events.jsonl")
+}
+
+// Append writes e to the event log, one JSON object per line. The log is
+// only ever appended to.
+func Append(e Event) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+// Load reads every event in path (Path() if empty), oldest first.
+// Lines that cannot be parsed, such as one cut short by a crash, are skipped.
+func Load(path string) ([]Event, error) {
+	if path == "" {
+		path = Path()
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []Event{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	out := []Event{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err == nil {
+			out = append(out, e)
+		}
+	}
+	return out, sc.Err()
+}
+
+// Attempt times one action; Done writes its event
+type Attempt struct {
+	ev     Event
+	start  time.Time
+	dryRun bool
+}
+
+// Start begins timing an action on profileURL. Dry runs write no event:
+// the dry-run log records them instead.
+func Start(typ Type, profileURL, campaignID string, dryRun bool) *Attempt {
+	return &Attempt{
+		ev:     Event{Type: typ, ProfileURL: profileURL, CampaignID: campaignID},
+		start:  time.Now(),
+		dryRun: dryRun,
+	}
+}
+
+// SetDetail sets the event's Detail
+func (a *Attempt) SetDetail(detail string) {
+	a.ev.Detail = detail
+}
+
+// Done records the attempt with its outcome and error. An empty outcome
+// is derived from err with OutcomeOf. Write failures are only logged so
+// they never fail the action itself.
+func (a *Attempt) Done(outcome string, err error) {
+	if a.dryRun {
+		return
+	}
+	if outcome == "" {
+		outcome = OutcomeOf(err)
+	}
+	a.ev.Time = a.start
+	a.ev.Outcome = outcome
+	a.ev.DurationMS = time.Since(a.start).Milliseconds()
+	if err != nil {
+		a.ev.Error = err.Error()
+	}
+	if err := Append(a.ev); err != nil {
+		slog.Warn("could not write event log", "error", err)
+	}
+}
+
+// OutcomeOf classifies the errors every action can end with
+func OutcomeOf(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, dnc.ErrBlocked):
+		return OutcomeBlocked
+	case errors.Is(err, ratelimit.ErrQuotaExceeded):
+		return OutcomeQuota
+	case errors.Is(err, context.Canceled):
+		return OutcomeCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimeout
+	}
+	return OutcomeFailed
+}
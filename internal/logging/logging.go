@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Field keys shared by every package, so log lines can be filtered by
+// campaign, prospect, action or sequence step
+const (
+	KeyCampaign = "campaign"
+	KeyProspect = "prospect"
+	KeyAction   = "action"
+	KeyStep     = "step"
+)
+
+// Output formats: text for people reading a terminal, JSON for files and
+// log tooling
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ParseLevel accepts debug, info, warn (or warning) and error
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (expected debug, info, warn or error)", s)
+}
+
+// New returns a logger writing records at level and above to w
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q (expected text or json)", format)
+}
+
+// Or returns l, or the default logger when l is nil, so a config without
+// a logger still logs
+func Or(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return slog.Default()
+	}
+	return l
+}
@@ -1,20 +1,28 @@
 package message
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
 
 	"github.com/sushmitaRN/linkedin-automation-poc/internal/behavior"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dnc"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dom"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dryrun"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/events"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
 	"github.com/sushmitaRN/linkedin-automation-poc/internal/ratelimit"
 )
 
@@ -27,6 +35,43 @@ Config & Models
 // MessageConfig controls messaging behavior and storage
 type MessageConfig struct {
 	StoragePath string
+	// Account selects the account-scope limits in data/limits.json
+	Account string
+	// CampaignID and Limits give the campaign-scope message limits
+	CampaignID string
+	Limits     []ratelimit.Limit
+	// TemplateID and TemplateDailyLimit give the template-scope limit
+	TemplateID         string
+	TemplateDailyLimit int
+	// DryRun types the message but stops before clicking send; the message
+	// goes to the dry-run log and nothing is recorded or counted
+	DryRun bool
+	// ApprovedBy is the reviewer who approved the text, stored with the record
+	ApprovedBy string
+	// Logger receives the messaging log lines (slog.Default() if nil)
+	Logger *slog.Logger
+}
+
+// logger returns cfg.Logger with the message's prospect and campaign
+func (cfg MessageConfig) logger(profileURL string) *slog.Logger {
+	return logging.Or(cfg.Logger).With(logging.KeyAction, "message", logging.KeyProspect, profileURL, logging.KeyCampaign, cfg.CampaignID)
+}
+
+// QuotaChain returns the global → account → campaign → template limits a
+// message is checked against
+func (cfg MessageConfig) QuotaChain() ([]ratelimit.ScopeLimits, error) {
+	qc, err := ratelimit.LoadConfig("")
+	if err != nil {
+		return nil, fmt.Errorf("load limits: %w", err)
+	}
+	var tplLimits []ratelimit.Limit
+	if cfg.TemplateDailyLimit > 0 {
+		tplLimits = []ratelimit.Limit{ratelimit.Daily(cfg.TemplateDailyLimit)}
+	}
+	return qc.Chain("message", cfg.Account,
+		ratelimit.ScopeLimits{Scope: ratelimit.CampaignScope(cfg.CampaignID), Limits: cfg.Limits},
+		ratelimit.ScopeLimits{Scope: ratelimit.TemplateScope(cfg.TemplateID), Limits: tplLimits},
+	), nil
 }
 
 // SentMessage record
@@ -34,8 +79,29 @@ type SentMessage struct {
 	ProfileURL string    `json:"profile_url"`
 	Message    string    `json:"message"`
 	Timestamp  time.Time `json:"timestamp"`
+	ApprovedBy string    `json:"approved_by,omitempty"`
+}
+
+// ErrNotConnected is returned when the connection has not been accepted yet
+var ErrNotConnected = errors.New("connection not accepted yet")
+
+// NotConnectedError carries the profile and the status text found on it.
+// errors.Is(err, ErrNotConnected) matches it.
+type NotConnectedError struct {
+	ProfileURL string
+	Status     string
+}
+
+func (e *NotConnectedError) Error() string {
+	if e.Status == "" {
+		return fmt.Sprintf("connection to %s not accepted yet", e.ProfileURL)
+	}
+	return fmt.Sprintf("connection to %s not accepted yet (status %q)", e.ProfileURL, e.Status)
 }
 
+// Is makes NotConnectedError match ErrNotConnected
+func (e *NotConnectedError) Is(target error) bool { return target == ErrNotConnected }
+
 /*
 ========================
 Selectors (centralized)
@@ -128,38 +194,55 @@ Public APIs
 */
 
 // SendMessageIfConnected sends a message only if connection is accepted
+// and the prospect has not replied yet (*RepliedError)
 func SendMessageIfConnected(
+	ctx context.Context,
 	page *rod.Page,
 	profileURL string,
 	template string,
 	vars map[string]string,
 	cfg MessageConfig,
-) error {
+) (err error) {
+	ev := events.Start(events.TypeMessage, profileURL, cfg.CampaignID, cfg.DryRun)
+	defer func() { ev.Done(eventOutcome(err), err) }()
+
 	if cfg.StoragePath == "" {
-		cfg.StoragePath = "data/sent_messages.json"
+		cfg.StoragePath = datadir.Path("sent_messages.json")
 	}
+	page = page.Context(ctx)
 
 	if err := page.Navigate(profileURL); err != nil {
-		return err
+		return fmt.Errorf("open %s: %w", profileURL, err)
+	}
+	if err := page.WaitLoad(); err != nil {
+		return fmt.Errorf("load %s: %w", profileURL, err)
 	}
-	page.MustWaitLoad()
 
-	log.Println("Reading profile for messaging...")
+	cfg.logger(profileURL).Debug("reading profile before messaging")
 	behavior.ReadingPause()
 	behavior.RandomScroll(page)
 	behavior.ReadingPause()
 
-	statusEl, err := page.Element(selectorConnectStatus)
-	if err != nil || statusEl == nil {
-		return fmt.Errorf("connection status not found on %s", profileURL)
+	statusEl, err := dom.Find(page, selectorConnectStatus, 0)
+	if err != nil {
+		return fmt.Errorf("connection status on %s: %w", profileURL, err)
 	}
 
-	statusText, _ := statusEl.Text()
-	statusText = strings.ToLower(statusText)
+	rawStatus, _ := statusEl.Text()
+	statusText := strings.ToLower(rawStatus)
 
 	if !strings.Contains(statusText, "accepted") &&
 		!strings.Contains(statusText, "connected") {
-		return errors.New("connection not accepted yet")
+		return &NotConnectedError{ProfileURL: profileURL, Status: strings.TrimSpace(rawStatus)}
+	}
+
+	// A follow-up is pointless once the prospect has written back
+	thread, err := ReadThread(page)
+	if err != nil {
+		return err
+	}
+	if reply, ok := LastReply(thread); ok {
+		return &RepliedError{ProfileURL: profileURL, Reply: reply}
 	}
 
 	return sendMessageCore(page, profileURL, template, vars, cfg)
@@ -167,27 +250,49 @@ func SendMessageIfConnected(
 
 // SendMessage sends a message without checking connection status
 func SendMessage(
+	ctx context.Context,
 	page *rod.Page,
 	profileURL string,
 	template string,
 	vars map[string]string,
 	cfg MessageConfig,
-) error {
+) (err error) {
+	ev := events.Start(events.TypeMessage, profileURL, cfg.CampaignID, cfg.DryRun)
+	defer func() { ev.Done(eventOutcome(err), err) }()
+
 	if cfg.StoragePath == "" {
-		cfg.StoragePath = "data/sent_messages.json"
+		cfg.StoragePath = datadir.Path("sent_messages.json")
 	}
+	page = page.Context(ctx)
 
 	if err := page.Navigate(profileURL); err != nil {
-		return err
+		return fmt.Errorf("open %s: %w", profileURL, err)
+	}
+	if err := page.WaitLoad(); err != nil {
+		return fmt.Errorf("load %s: %w", profileURL, err)
 	}
-	page.MustWaitLoad()
 
-	log.Println("Preparing page for messaging...")
+	cfg.logger(profileURL).Debug("preparing page for messaging")
 	behavior.ReadingPause()
 
 	return sendMessageCore(page, profileURL, template, vars, cfg)
 }
 
+// eventOutcome is the event log outcome for a message attempt
+func eventOutcome(err error) string {
+	switch {
+	case err == nil:
+		return "sent"
+	case errors.Is(err, ErrNotConnected):
+		return "not_connected"
+	case errors.Is(err, ErrReplied):
+		return "replied"
+	case errors.Is(err, ErrPolicy):
+		return "policy_violation"
+	}
+	return events.OutcomeOf(err)
+}
+
 /*
 ========================
 Core send logic
@@ -201,55 +306,88 @@ func sendMessageCore(
 	vars map[string]string,
 	cfg MessageConfig,
 ) error {
-	// Check quota (do NOT increment yet)
-	if err := ratelimit.Check("message", 5, "data/quotas.json"); err != nil {
+	lg := cfg.logger(profileURL)
+
+	// Refuse prospects on the do-not-contact list
+	target := dnc.Target{ProfileURL: profileURL, Name: dom.Text(page, "#name"), Company: vars["company"]}
+	if target.Company == "" {
+		target.Company = dom.Text(page, "#company")
+	}
+	if err := dnc.Guard("message", target, cfg.DryRun, lg); err != nil {
 		return err
 	}
 
-	msg, err := RenderTemplate(template, vars)
+	// Render and check the text before touching the quota, so a bad
+	// template costs nothing
+	policy, err := LoadPolicy("")
 	if err != nil {
-		return err
+		return fmt.Errorf("load policy: %w", err)
+	}
+	msg, err := policy.Render(template, vars)
+	if err != nil {
+		return fmt.Errorf("message to %s: %w", profileURL, err)
 	}
 
-	if len(msg) > 500 {
-		return errors.New("message too long (max 500 chars)")
+	// Hold a quota slot; it is only counted once the message is sent
+	chain, err := cfg.QuotaChain()
+	if err != nil {
+		return err
+	}
+	tok, err := ratelimit.Reserve("message", chain, datadir.Path("quotas.json"))
+	if err != nil {
+		return err
 	}
+	defer tok.Release()
 
-	box, err := page.Element(selectorMessageBox)
-	if err != nil || box == nil {
-		return fmt.Errorf("message box not found on %s", profileURL)
+	box, err := dom.Find(page, selectorMessageBox, 0)
+	if err != nil {
+		return fmt.Errorf("message box on %s: %w", profileURL, err)
 	}
 
-	log.Printf("Typing message (%d chars)...", len(msg))
+	lg.Debug("typing message", "chars", utf8.RuneCountInString(msg))
 	if err := behavior.HumanType(box, msg); err != nil {
-		return err
+		return fmt.Errorf("type message: %w", err)
 	}
 
-	log.Println("Reviewing message...")
+	lg.Debug("reviewing message")
 	behavior.ReadingPause()
 
-	sendBtn, err := page.Element(selectorSendButton)
-	if err != nil || sendBtn == nil {
-		return fmt.Errorf("send button not found on %s", profileURL)
+	sendBtn, err := dom.Find(page, selectorSendButton, 0)
+	if err != nil {
+		return fmt.Errorf("send button on %s: %w", profileURL, err)
 	}
 
-	log.Println("Clicking send...")
+	if cfg.DryRun {
+		lg.Info("dry run: would send message")
+		if err := dryrun.Record(dryrun.Entry{
+			Action:     "message",
+			ProfileURL: profileURL,
+			CampaignID: cfg.CampaignID,
+			Detail:     msg,
+		}); err != nil {
+			lg.Warn("could not write dry-run log", "error", err)
+		}
+		return nil
+	}
+
+	lg.Debug("clicking send")
 	if err := sendBtn.Click(proto.InputMouseButtonLeft, 1); err != nil {
-		return err
+		return fmt.Errorf("click send: %w", err)
 	}
 
-	// Increment quota only after successful send
-	if err := ratelimit.Increment("message", "data/quotas.json"); err != nil {
-		log.Printf("warning: quota increment failed: %v", err)
+	// Count the quota only after a successful send
+	if err := tok.Commit(); err != nil {
+		lg.Warn("quota increment failed", "error", err)
 	}
 
-	log.Println("✓ Message sent")
+	lg.Info("message sent")
 	behavior.ReadingPause()
 
 	saveMessageSafe(cfg.StoragePath, SentMessage{
 		ProfileURL: profileURL,
 		Message:    msg,
 		Timestamp:  time.Now(),
+		ApprovedBy: cfg.ApprovedBy,
 	})
 
 	return nil
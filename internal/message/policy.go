@@ -0,0 +1,189 @@
+package message
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxRunes is the message length limit, in characters
+const DefaultMaxRunes = 500
+
+// Policy is checked against every rendered message and connect note
+// before it is typed
+type Policy struct {
+	// MaxRunes limits the length in characters, not bytes
+	MaxRunes int `json:"max_runes"`
+	// BannedPhrases are refused anywhere in the text, ignoring case
+	BannedPhrases []string `json:"banned_phrases"`
+	// AllowLinks permits URLs and bare domains
+	AllowLinks bool `json:"allow_links"`
+}
+
+// DefaultPolicyPath is where LoadPolicy looks when no path is given
+var DefaultPolicyPath = "data/policy.json"
+
+// DefaultPolicy is used when the policy file does not exist
+var DefaultPolicy = Policy{MaxRunes: DefaultMaxRunes}
+
+// LoadPolicy reads the content policy
+func LoadPolicy(path string) (Policy, error) {
+	if path == "" {
+		path = DefaultPolicyPath
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DefaultPolicy, nil
+	}
+	if err != nil {
+		return Policy{}, err
+	}
+	p := DefaultPolicy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return Policy{}, fmt.Errorf("policy %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// ErrPolicy is returned when a text breaks the content policy
+var ErrPolicy = errors.New("content policy violation")
+
+// Violation is one broken rule, with what to change
+type Violation struct {
+	Rule   string
+	Detail string
+	Fix    string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s (%s)", v.Rule, v.Detail, v.Fix)
+}
+
+// PolicyError lists every rule a text broke.
+// errors.Is(err, ErrPolicy) matches it.
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.String()
+	}
+	return "content policy: " + strings.Join(parts, "; ")
+}
+
+// Is makes PolicyError match ErrPolicy
+func (e *PolicyError) Is(target error) bool { return target == ErrPolicy }
+
+var (
+	placeholderRe = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+	// a gap before punctuation is what an empty substitution leaves: "at ."
+	danglingRe = regexp.MustCompile(`\S*[ \t]+[.,;:!?]`)
+	linkRe     = regexp.MustCompile(`(?i)\bhttps?://\S+|\bwww\.\S+|\b[a-z0-9][a-z0-9-]*\.(?:com|net|org|io|co|ai|me|ly|app|dev)\b(?:/\S*)?`)
+)
+
+// Render fills tpl with vars and checks the result against p
+func (p Policy) Render(tpl string, vars map[string]string) (string, error) {
+	out := tpl
+	for k, v := range vars {
+		out = strings.ReplaceAll(out, "{{"+k+"}}", v)
+	}
+	if err := p.Check(tpl, vars, out); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// Check returns a *PolicyError if msg, rendered from tpl with vars, has
+// unresolved placeholders or empty substitutions, is too long, or contains
+// a banned phrase or (unless allowed) a link. tpl may be empty when only
+// the final text is known.
+func (p Policy) Check(tpl string, vars map[string]string, msg string) error {
+	var vs []Violation
+
+	if names := placeholders(msg); len(names) > 0 {
+		vs = append(vs, Violation{
+			Rule:   "unresolved_placeholder",
+			Detail: "{{" + strings.Join(names, "}}, {{") + "}} left in the text",
+			Fix:    "add the variable to the prospect or remove it from the template",
+		})
+	}
+
+	var empty []string
+	for _, name := range placeholders(tpl) {
+		if v, ok := vars[name]; ok && strings.TrimSpace(v) == "" {
+			empty = append(empty, name)
+		}
+	}
+	if len(empty) > 0 {
+		vs = append(vs, Violation{
+			Rule:   "empty_variable",
+			Detail: "{{" + strings.Join(empty, "}}, {{") + "}} rendered empty",
+			Fix:    "fill in the prospect's data or use a template without it",
+		})
+	} else if m := danglingRe.FindString(msg); m != "" && tpl != "" && len(placeholders(tpl)) > 0 {
+		vs = append(vs, Violation{
+			Rule:   "empty_variable",
+			Detail: fmt.Sprintf("text %q looks like a missing value", strings.TrimSpace(m)),
+			Fix:    "check the variables used before the punctuation",
+		})
+	}
+
+	limit := p.MaxRunes
+	if limit <= 0 {
+		limit = DefaultMaxRunes
+	}
+	if n := utf8.RuneCountInString(msg); n > limit {
+		vs = append(vs, Violation{
+			Rule:   "too_long",
+			Detail: fmt.Sprintf("%d characters, max %d", n, limit),
+			Fix:    fmt.Sprintf("shorten by %d characters", n-limit),
+		})
+	}
+
+	lower := strings.ToLower(msg)
+	for _, phrase := range p.BannedPhrases {
+		if phrase = strings.TrimSpace(phrase); phrase != "" && strings.Contains(lower, strings.ToLower(phrase)) {
+			vs = append(vs, Violation{
+				Rule:   "banned_phrase",
+				Detail: fmt.Sprintf("contains %q", phrase),
+				Fix:    "reword the template",
+			})
+		}
+	}
+
+	if !p.AllowLinks {
+		if link := linkRe.FindString(msg); link != "" {
+			vs = append(vs, Violation{
+				Rule:   "link",
+				Detail: fmt.Sprintf("contains %q", link),
+				Fix:    "remove the link or set allow_links in the policy",
+			})
+		}
+	}
+
+	if len(vs) == 0 {
+		return nil
+	}
+	return &PolicyError{Violations: vs}
+}
+
+// placeholders returns the distinct {{name}} tokens of s, sorted
+func placeholders(s string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, m := range placeholderRe.FindAllStringSubmatch(s, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			out = append(out, m[1])
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,97 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// Direction tells who wrote a thread message
+type Direction string
+
+const (
+	Outbound Direction = "outbound"
+	Inbound  Direction = "inbound"
+)
+
+// ThreadMessage is one message of the conversation shown on a profile
+type ThreadMessage struct {
+	Direction Direction
+	Sender    string
+	Text      string
+	// SentAt is zero if the page does not show when it was sent
+	SentAt time.Time
+}
+
+const (
+	selectorThreadMessage = "#message-thread .thread-message"
+	selectorThreadSender  = ".thread-sender"
+	selectorThreadText    = ".thread-text"
+)
+
+// ErrReplied is returned instead of sending when the prospect has already
+// written back
+var ErrReplied = errors.New("prospect replied")
+
+// RepliedError carries the prospect's latest reply.
+// errors.Is(err, ErrReplied) matches it.
+type RepliedError struct {
+	ProfileURL string
+	Reply      ThreadMessage
+}
+
+func (e *RepliedError) Error() string {
+	return fmt.Sprintf("%s replied at %s: %q", e.ProfileURL, e.Reply.SentAt.Format(time.RFC3339), e.Reply.Text)
+}
+
+// Is makes RepliedError match ErrReplied
+func (e *RepliedError) Is(target error) bool { return target == ErrReplied }
+
+// ReadThread parses the message thread of the open profile page, oldest
+// first. A page without a thread yields an empty slice.
+func ReadThread(page *rod.Page) ([]ThreadMessage, error) {
+	els, err := page.Elements(selectorThreadMessage)
+	if err != nil {
+		return nil, fmt.Errorf("read message thread: %w", err)
+	}
+
+	out := make([]ThreadMessage, 0, len(els))
+	for _, el := range els {
+		var m ThreadMessage
+		if dir, err := el.Attribute("data-direction"); err == nil && dir != nil {
+			m.Direction = Direction(*dir)
+		}
+		if at, err := el.Attribute("data-sent-at"); err == nil && at != nil {
+			if t, err := time.Parse(time.RFC3339, *at); err == nil {
+				m.SentAt = t
+			}
+		}
+		if has, s, err := el.Has(selectorThreadSender); err == nil && has {
+			if txt, err := s.Text(); err == nil {
+				m.Sender = strings.TrimSpace(txt)
+			}
+		}
+		if has, t, err := el.Has(selectorThreadText); err == nil && has {
+			txt, err := t.Text()
+			if err != nil {
+				return nil, fmt.Errorf("read thread message: %w", err)
+			}
+			m.Text = strings.TrimSpace(txt)
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// LastReply returns the newest inbound message of thread, if any
+func LastReply(thread []ThreadMessage) (ThreadMessage, bool) {
+	for i := len(thread) - 1; i >= 0; i-- {
+		if thread[i].Direction == Inbound {
+			return thread[i], true
+		}
+	}
+	return ThreadMessage{}, false
+}
@@ -1,7 +1,9 @@
 package post
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 	"math/rand"
 	"strings"
 	"time"
@@ -9,17 +11,32 @@ import (
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
 	"github.com/sushmitaRN/linkedin-automation-poc/internal/behavior"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dnc"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dom"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dryrun"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/events"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
 )
 
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
+// Config controls post interaction
+type Config struct {
+	// DryRun stops before the like or post-comment click and logs the
+	// action to the dry-run log instead
+	DryRun bool
+	// Logger receives the post interaction log lines (slog.Default() if nil)
+	Logger *slog.Logger
+}
+
 // ScrollToElement smoothly scrolls to an element with human-like behavior
-func ScrollToElement(page *rod.Page, element *rod.Element) error {
+func ScrollToElement(ctx context.Context, page *rod.Page, element *rod.Element) error {
 	if page == nil || element == nil {
 		return nil
 	}
+	page = page.Context(ctx)
 
 	// Get element position using JavaScript
 	result, err := page.Eval(`(el) => {
@@ -54,6 +71,9 @@ func ScrollToElement(page *rod.Page, element *rod.Element) error {
 	}
 
 	for i := 0; i < steps; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		scrollY := currentY + (stepSize * i)
 		page.Eval(`(y) => { window.scrollTo(0, y); }`, scrollY)
 		behavior.SleepHuman(50*time.Millisecond, 150*time.Millisecond)
@@ -67,16 +87,17 @@ func ScrollToElement(page *rod.Page, element *rod.Element) error {
 }
 
 // HumanScroll scrolls the page like a human would - gradually and with pauses
-func HumanScroll(page *rod.Page, scrollAmount int) {
+func HumanScroll(ctx context.Context, page *rod.Page, scrollAmount int) {
 	if page == nil {
 		return
 	}
+	page = page.Context(ctx)
 
 	// Scroll in smaller increments with pauses
 	steps := 3 + rand.Intn(5) // 3-7 steps
 	stepSize := scrollAmount / steps
 
-	for i := 0; i < steps; i++ {
+	for i := 0; i < steps && ctx.Err() == nil; i++ {
 		page.Eval(`(amount) => { window.scrollBy(0, amount); }`, stepSize)
 		behavior.SleepHuman(200*time.Millisecond, 500*time.Millisecond)
 	}
@@ -86,17 +107,32 @@ func HumanScroll(page *rod.Page, scrollAmount int) {
 }
 
 // LikePost likes a post by clicking the like button
-func LikePost(page *rod.Page, postElement *rod.Element) error {
+func LikePost(ctx context.Context, page *rod.Page, postElement *rod.Element, cfg Config) error {
 	if postElement == nil {
 		return nil
 	}
+	ev := events.Start(events.TypeLike, "", "", cfg.DryRun)
+	ev.SetDetail(postDetail(postElement))
+	cfg.Logger = logging.Or(cfg.Logger).With(logging.KeyAction, "like", "post", postDetail(postElement))
+	err := likePost(ctx, page, postElement, cfg)
+	ev.Done("", err)
+	return err
+}
+
+func likePost(ctx context.Context, page *rod.Page, postElement *rod.Element, cfg Config) error {
+	postElement = postElement.Context(ctx)
+
+	target := postTarget(postElement)
+	if err := dnc.Guard("like", target, cfg.DryRun, cfg.Logger.With(logging.KeyProspect, target.ProfileURL)); err != nil {
+		return err
+	}
 
 	// Find the like button within this post - try multiple selectors
 	var likeBtn *rod.Element
 	var err error
 
 	// Try .like-btn first
-	likeBtn, err = postElement.Element(".like-btn")
+	likeBtn, err = dom.FindIn(postElement, ".like-btn", 2*time.Second)
 	if err != nil || likeBtn == nil {
 		// Try finding button with onclick containing toggleLike
 		allButtons, _ := postElement.Elements("button")
@@ -110,8 +146,7 @@ func LikePost(page *rod.Page, postElement *rod.Element) error {
 	}
 
 	if likeBtn == nil {
-		log.Printf("Like button not found for post")
-		return nil
+		return fmt.Errorf("like button: %w", dom.ErrElementNotFound)
 	}
 
 	// Scroll to the post first
@@ -129,38 +164,61 @@ func LikePost(page *rod.Page, postElement *rod.Element) error {
 	}
 
 	if !isLiked {
-		log.Println("Liking post...")
+		cfg.Logger.Debug("liking post")
 		// Scroll to button
 		likeBtn.ScrollIntoView()
 		time.Sleep(300 * time.Millisecond)
 
+		if cfg.DryRun {
+			logDryRun("like", page, "", cfg.Logger)
+			return nil
+		}
+
 		if err := likeBtn.Click(proto.InputMouseButtonLeft, 1); err != nil {
-			log.Printf("Error clicking like button: %v", err)
-			return err
+			return fmt.Errorf("click like: %w", err)
 		}
-		log.Println("✓ Post liked")
+		cfg.Logger.Info("post liked")
 		time.Sleep(500 * time.Millisecond)
 	} else {
-		log.Println("Post already liked, skipping")
+		cfg.Logger.Info("post already liked, skipping")
 	}
 
 	return nil
 }
 
 // CommentOnPost adds a comment to a post
-func CommentOnPost(page *rod.Page, postElement *rod.Element, commentText string) error {
+func CommentOnPost(ctx context.Context, page *rod.Page, postElement *rod.Element, commentText string, cfg Config) error {
 	if postElement == nil {
 		return nil
 	}
+	ev := events.Start(events.TypeComment, "", "", cfg.DryRun)
+	ev.SetDetail(postDetail(postElement))
+	cfg.Logger = logging.Or(cfg.Logger).With(logging.KeyAction, "comment", "post", postDetail(postElement))
+	err := commentOnPost(ctx, page, postElement, commentText, cfg)
+	ev.Done("", err)
+	return err
+}
+
+func commentOnPost(ctx context.Context, page *rod.Page, postElement *rod.Element, commentText string, cfg Config) error {
+	page = page.Context(ctx)
+	postElement = postElement.Context(ctx)
+
+	target := postTarget(postElement)
+	if err := dnc.Guard("comment", target, cfg.DryRun, cfg.Logger.With(logging.KeyProspect, target.ProfileURL)); err != nil {
+		return err
+	}
 
 	// Get post ID from data attribute
-	postID, _ := postElement.Attribute("data-post-id")
+	postID, err := postElement.Attribute("data-post-id")
+	if err != nil {
+		return fmt.Errorf("read post id: %w", err)
+	}
 	if postID == nil {
-		log.Println("Could not find post ID")
-		return nil
+		return fmt.Errorf("post id attribute: %w", dom.ErrElementNotFound)
 	}
 
-	log.Printf("Commenting on post ID: %s", *postID)
+	lg := cfg.Logger
+	lg.Debug("commenting on post")
 
 	// Scroll to post first
 	postElement.ScrollIntoView()
@@ -193,49 +251,44 @@ func CommentOnPost(page *rod.Page, postElement *rod.Element, commentText string)
 	}
 
 	if commentToggleBtn != nil {
-		log.Println("Found comment toggle button, clicking to expand...")
+		lg.Debug("found comment toggle button, clicking to expand")
 		commentToggleBtn.ScrollIntoView()
 		time.Sleep(300 * time.Millisecond)
 
 		// Click to expand comments section
 		if err := commentToggleBtn.Click(proto.InputMouseButtonLeft, 1); err != nil {
-			log.Printf("Could not click comment toggle: %v", err)
+			lg.Warn("could not click comment toggle", "error", err)
 		} else {
-			log.Println("✓ Expanded comments section")
+			lg.Debug("expanded comments section")
 			time.Sleep(1 * time.Second) // Wait for comment section to appear
 		}
 	} else {
-		log.Println("Comment toggle button not found, trying to find comment input directly...")
+		lg.Debug("comment toggle button not found, looking for the comment input directly")
 	}
 
 	// Wait a bit for comment section to appear/be visible
 	time.Sleep(1 * time.Second)
 
 	// Find comment input - try multiple selectors
-	var commentInput *rod.Element
-	commentInput, err := page.Element("#comment-input-" + *postID)
-	if err != nil || commentInput == nil {
+	commentInput, err := dom.Find(page, "#comment-input-"+*postID, 2*time.Second)
+	if err != nil {
 		// Try finding within post element
-		commentSection, _ := postElement.Element("#comments-" + *postID)
-		if commentSection != nil {
-			commentInput, _ = commentSection.Element("input")
+		if commentSection, findErr := dom.FindIn(postElement, "#comments-"+*postID, 2*time.Second); findErr == nil {
+			commentInput, err = dom.FindIn(commentSection, "input", 2*time.Second)
 		}
 	}
-
-	if commentInput == nil {
-		log.Printf("Comment input not found for post ID %s", *postID)
-		return nil
+	if err != nil {
+		return fmt.Errorf("comment input for post %s: %w", *postID, err)
 	}
 
-	log.Println("Found comment input, scrolling to it...")
+	lg.Debug("found comment input, scrolling to it")
 	commentInput.ScrollIntoView()
 	time.Sleep(500 * time.Millisecond)
 
 	// Type comment
-	log.Printf("Typing comment: %s", commentText)
+	lg.Debug("typing comment", "text", commentText)
 	if err := behavior.HumanType(commentInput, commentText); err != nil {
-		log.Printf("Error typing comment: %v", err)
-		return err
+		return fmt.Errorf("type comment: %w", err)
 	}
 
 	time.Sleep(500 * time.Millisecond)
@@ -256,50 +309,53 @@ func CommentOnPost(page *rod.Page, postElement *rod.Element, commentText string)
 
 	// Method 2: Find button in comment section
 	if postBtn == nil {
-		commentSection, _ := postElement.Element("#comments-" + *postID)
-		if commentSection != nil {
-			commentInputDiv, _ := commentSection.Element(".comment-input")
-			if commentInputDiv != nil {
-				postBtn, _ = commentInputDiv.Element("button")
+		if commentSection, err := dom.FindIn(postElement, "#comments-"+*postID, 2*time.Second); err == nil {
+			if commentInputDiv, err := dom.FindIn(commentSection, ".comment-input", 2*time.Second); err == nil {
+				postBtn, _ = dom.FindIn(commentInputDiv, "button", 2*time.Second)
 			}
 		}
 	}
 
 	if postBtn != nil {
-		log.Println("Found post button, clicking...")
+		lg.Debug("found post button, clicking")
 		postBtn.ScrollIntoView()
 		time.Sleep(300 * time.Millisecond)
 
+		if cfg.DryRun {
+			logDryRun("comment", page, commentText, lg)
+			return nil
+		}
+
 		if err := postBtn.Click(proto.InputMouseButtonLeft, 1); err != nil {
-			log.Printf("Error clicking post button: %v", err)
-			return err
+			return fmt.Errorf("click post comment: %w", err)
 		}
-		log.Println("✓ Comment posted successfully")
+		lg.Info("comment posted")
 		time.Sleep(1 * time.Second)
 	} else {
-		log.Println("Could not find post button for comment")
-		return nil
+		return fmt.Errorf("post comment button for post %s: %w", *postID, dom.ErrElementNotFound)
 	}
 
 	return nil
 }
 
 // InteractWithPosts scrolls through posts, likes some, and comments on some
-func InteractWithPosts(page *rod.Page, maxPosts int) error {
-	log.Println("\n=== Starting Post Interaction ===")
+func InteractWithPosts(ctx context.Context, page *rod.Page, maxPosts int, cfg Config) error {
+	lg := logging.Or(cfg.Logger)
+	lg.Info("starting post interaction")
+	page = page.Context(ctx)
 
 	// Find all posts
 	posts, err := page.Elements(".post")
 	if err != nil {
-		return err
+		return fmt.Errorf("find posts: %w", err)
 	}
 
 	if len(posts) == 0 {
-		log.Println("No posts found on page")
+		lg.Info("no posts found on page")
 		return nil
 	}
 
-	log.Printf("Found %d posts, will interact with up to %d", len(posts), maxPosts)
+	lg.Info("found posts", "count", len(posts), "max", maxPosts)
 
 	// Limit the number of posts to interact with
 	if maxPosts > len(posts) {
@@ -319,12 +375,15 @@ func InteractWithPosts(page *rod.Page, maxPosts int) error {
 	}
 
 	for i := 0; i < maxPosts; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		post := posts[i]
-		log.Printf("\n--- Interacting with post %d/%d ---", i+1, maxPosts)
+		lg.Debug("interacting with post", "n", i+1, "of", maxPosts)
 
 		// Scroll to post
-		if err := ScrollToElement(page, post); err != nil {
-			log.Printf("Could not scroll to post: %v", err)
+		if err := ScrollToElement(ctx, page, post); err != nil {
+			lg.Warn("could not scroll to post", "error", err)
 			continue
 		}
 
@@ -333,24 +392,57 @@ func InteractWithPosts(page *rod.Page, maxPosts int) error {
 		behavior.ReadingPause()
 
 		// Always like the post
-		log.Println("Attempting to like post...")
-		if err := LikePost(page, post); err != nil {
-			log.Printf("Error liking post: %v", err)
+		if err := LikePost(ctx, page, post, cfg); err != nil {
+			lg.Warn("could not like post", "error", err)
 		}
 
 		// Always comment on the post
 		commentText := comments[rand.Intn(len(comments))]
-		log.Println("Attempting to comment on post...")
-		if err := CommentOnPost(page, post, commentText); err != nil {
-			log.Printf("Error commenting on post: %v", err)
+		if err := CommentOnPost(ctx, page, post, commentText, cfg); err != nil {
+			lg.Warn("could not comment on post", "error", err)
 		}
 
 		// Scroll down a bit before next post
 		if i < maxPosts-1 {
-			HumanScroll(page, 200+rand.Intn(300))
+			HumanScroll(ctx, page, 200+rand.Intn(300))
 		}
 	}
 
-	log.Println("\n=== Post Interaction Complete ===")
+	lg.Info("post interaction complete")
 	return nil
 }
+
+// postTarget returns the post's author for the do-not-contact check
+func postTarget(postElement *rod.Element) dnc.Target {
+	t := dnc.Target{}
+	if has, el, err := postElement.Has(".post-author"); err == nil && has {
+		if txt, err := el.Text(); err == nil {
+			t.Name = strings.TrimSpace(txt)
+		}
+	}
+	return t
+}
+
+// postDetail identifies a post in the event log: "post <id> by <author>"
+func postDetail(postElement *rod.Element) string {
+	detail := "post"
+	if id, err := postElement.Attribute("data-post-id"); err == nil && id != nil {
+		detail += " " + *id
+	}
+	if author := postTarget(postElement).Name; author != "" {
+		detail += " by " + author
+	}
+	return detail
+}
+
+// logDryRun records a skipped post action against the page it was on
+func logDryRun(action string, page *rod.Page, detail string, lg *slog.Logger) {
+	url := ""
+	if info, err := page.Info(); err == nil {
+		url = info.URL
+	}
+	lg.Info("dry run: would "+action+" post", "url", url)
+	if err := dryrun.Record(dryrun.Entry{Action: action, ProfileURL: url, Detail: detail}); err != nil {
+		lg.Warn("could not write dry-run log", "error", err)
+	}
+}
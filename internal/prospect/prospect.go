@@ -0,0 +1,165 @@
+package prospect
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+)
+
+// Status is where a prospect stands in the outreach
+type Status string
+
+const (
+	StatusNew Status = "new"
+	// StatusInvited prospects have a confirmed connect request
+	StatusInvited Status = "invited"
+	// StatusInSequence prospects have received at least one sequence message
+	StatusInSequence Status = "in_sequence"
+	// StatusCompleted prospects have received every step of the sequence
+	StatusCompleted Status = "completed"
+	// StatusSkipped prospects are not contacted; SkipReason says why
+	StatusSkipped Status = "skipped"
+	// StatusReplied prospects wrote back; their follow-ups are cancelled
+	StatusReplied Status = "replied"
+)
+
+// Prospect is everything known about one profile, keyed by its URL
+type Prospect struct {
+	ProfileURL string `json:"profile_url"`
+	Name       string `json:"name,omitempty"`
+	Company    string `json:"company,omitempty"`
+	CampaignID string `json:"campaign_id,omitempty"`
+	Status     Status `json:"status"`
+	// Step is the last sequence step done and LastContactAt when
+	Step          int        `json:"step,omitempty"`
+	LastContactAt *time.Time `json:"last_contact_at,omitempty"`
+	// SkipReason and SkippedAt are set when an action was refused
+	SkipReason string     `json:"skip_reason,omitempty"`
+	SkippedAt  *time.Time `json:"skipped_at,omitempty"`
+	// RepliedAt is when the prospect's reply was first seen
+	RepliedAt *time.Time `json:"replied_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Path returns the prospect store file
+func Path() string {
+	return datadir.Path("prospects.json")
+}
+
+var mu sync.Mutex
+
+// Load returns every prospect in path (Path() if empty), sorted by URL
+func Load(path string) ([]Prospect, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	m, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Prospect, 0, len(m))
+	for _, p := range m {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ProfileURL < out[j].ProfileURL })
+	return out, nil
+}
+
+// Get returns the prospect for profileURL, if there is one
+func Get(path, profileURL string) (Prospect, bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	m, err := load(path)
+	if err != nil {
+		return Prospect{}, false, err
+	}
+	p, ok := m[profileURL]
+	return p, ok, nil
+}
+
+// Update applies fn to the prospect for profileURL, creating it if needed,
+// and saves the store
+func Update(path, profileURL string, fn func(p *Prospect)) error {
+	mu.Lock()
+	defer mu.Unlock()
+	m, err := load(path)
+	if err != nil {
+		return err
+	}
+	p, ok := m[profileURL]
+	if !ok {
+		p = Prospect{ProfileURL: profileURL, Status: StatusNew}
+	}
+	fn(&p)
+	p.UpdatedAt = time.Now()
+	m[profileURL] = p
+	return save(path, m)
+}
+
+// MarkSkipped records that an action on profileURL was refused and why
+func MarkSkipped(path, profileURL, reason string) error {
+	return Update(path, profileURL, func(p *Prospect) {
+		now := time.Now()
+		p.Status = StatusSkipped
+		p.SkipReason = reason
+		p.SkippedAt = &now
+	})
+}
+
+// MarkReplied records that the prospect wrote back at (now if zero)
+func MarkReplied(path, profileURL string, at time.Time) error {
+	return Update(path, profileURL, func(p *Prospect) {
+		if at.IsZero() {
+			at = time.Now()
+		}
+		p.Status = StatusReplied
+		if p.RepliedAt == nil {
+			p.RepliedAt = &at
+		}
+	})
+}
+
+func load(path string) (map[string]Prospect, error) {
+	if path == "" {
+		path = Path()
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]Prospect{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var arr []Prospect
+	if err := json.Unmarshal(b, &arr); err != nil {
+		return nil, err
+	}
+	m := make(map[string]Prospect, len(arr))
+	for _, p := range arr {
+		m[p.ProfileURL] = p
+	}
+	return m, nil
+}
+
+func save(path string, m map[string]Prospect) error {
+	if path == "" {
+		path = Path()
+	}
+	arr := make([]Prospect, 0, len(m))
+	for _, p := range m {
+		arr = append(arr, p)
+	}
+	sort.Slice(arr, func(i, j int) bool { return arr[i].ProfileURL < arr[j].ProfileURL })
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(arr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
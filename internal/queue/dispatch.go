@@ -0,0 +1,184 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/go-rod/rod"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
+)
+
+// Dispatcher runs queued jobs in priority order.
+//
+// With the zero value every job runs sequentially on Page. Setting
+// MaxWorkers > 1 together with NewPage lets jobs of different types run
+// side by side, each worker on its own page, within the per-type limits.
+type Dispatcher struct {
+	Page *rod.Page
+	// NewPage opens an extra page for concurrent workers
+	NewPage func() (*rod.Page, error)
+
+	// MaxWorkers caps the total number of jobs running at once (default 1)
+	MaxWorkers int
+	// Concurrency caps running jobs per type (default 1 per type)
+	Concurrency map[JobType]int
+	// Quotas caps how many jobs of a type run per Run call; others are
+	// returned as deferred. Zero or missing means unlimited.
+	Quotas map[JobType]int
+	// JobTimeout bounds each job; zero means only ctx applies
+	JobTimeout time.Duration
+	// HaltType, if set, reports whether a job error means no other job of
+	// the same type can succeed this run (e.g. a quota error). The rest of
+	// that type are then returned as deferred without running.
+	HaltType func(err error) bool
+	// Logger receives failed jobs and halts (slog.Default() if nil)
+	Logger *slog.Logger
+}
+
+// Result is the outcome of one Run call
+type Result struct {
+	Done     int
+	Failed   int
+	Deferred []Job
+}
+
+type finished struct {
+	job  Job
+	page *rod.Page
+	err  error
+}
+
+// Run executes jobs from q until it is empty. Once ctx is done no new jobs
+// start and the remaining ones are returned as deferred; running jobs see
+// the cancellation through their own context.
+func (d *Dispatcher) Run(ctx context.Context, q *Queue) Result {
+	lg := logging.Or(d.Logger)
+	maxWorkers := d.MaxWorkers
+	if maxWorkers <= 0 || d.NewPage == nil {
+		maxWorkers = 1
+	}
+
+	var res Result
+	running := map[JobType]int{}
+	started := map[JobType]int{}
+	halted := map[JobType]bool{}
+	idle := []*rod.Page{}
+	if d.Page != nil {
+		idle = append(idle, d.Page)
+	}
+	opened := []*rod.Page{}
+	defer func() {
+		for _, p := range opened {
+			_ = p.Close()
+		}
+	}()
+
+	done := make(chan finished)
+	inFlight := 0
+
+	allow := func(j Job) bool {
+		limit := d.Concurrency[j.Type]
+		if limit <= 0 {
+			limit = 1
+		}
+		return running[j.Type] < limit
+	}
+
+	for {
+		stopping := ctx.Err() != nil
+
+		// start as many jobs as the limits allow
+		for !stopping && inFlight < maxWorkers {
+			job, ok := q.Pop(allow)
+			if !ok {
+				break
+			}
+			if halted[job.Type] {
+				res.Deferred = append(res.Deferred, job)
+				continue
+			}
+			if quota, ok := d.Quotas[job.Type]; ok && quota > 0 && started[job.Type] >= quota {
+				res.Deferred = append(res.Deferred, job)
+				continue
+			}
+
+			var page *rod.Page
+			if len(idle) > 0 {
+				page, idle = idle[len(idle)-1], idle[:len(idle)-1]
+			} else if d.NewPage != nil {
+				p, err := d.NewPage()
+				if err != nil {
+					lg.Warn("could not open worker page", "error", err)
+					q.Push(job)
+					break
+				}
+				opened = append(opened, p)
+				page = p
+			} else {
+				q.Push(job)
+				break
+			}
+
+			running[job.Type]++
+			started[job.Type]++
+			inFlight++
+			go func(job Job, page *rod.Page) {
+				done <- finished{job: job, page: page, err: d.runJob(ctx, job, page)}
+			}(job, page)
+		}
+
+		if inFlight == 0 {
+			break
+		}
+
+		f := <-done
+		inFlight--
+		running[f.job.Type]--
+		idle = append(idle, f.page)
+		if f.err != nil {
+			res.Failed++
+			lg.Warn("job failed", logging.KeyAction, f.job.Type, logging.KeyProspect, f.job.ProfileURL, logging.KeyCampaign, f.job.CampaignID, "error", f.err)
+			if d.HaltType != nil && !halted[f.job.Type] && d.HaltType(f.err) {
+				halted[f.job.Type] = true
+				lg.Warn("halting remaining jobs of this type this run", logging.KeyAction, f.job.Type)
+			}
+		} else {
+			res.Done++
+		}
+	}
+
+	res.Deferred = append(res.Deferred, q.Drain()...)
+	return res
+}
+
+// runJob runs one job, turning a panic from a Must* call into an error so a
+// single prospect cannot take the dispatcher down.
+func (d *Dispatcher) runJob(ctx context.Context, job Job, page *rod.Page) (err error) {
+	if job.Run == nil {
+		return errors.New("job has no Run func")
+	}
+	if d.JobTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.JobTimeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("job panicked: " + panicString(r))
+		}
+	}()
+	return job.Run(ctx, page)
+}
+
+func panicString(r any) string {
+	if e, ok := r.(error); ok {
+		return e.Error()
+	}
+	if s, ok := r.(string); ok {
+		return s
+	}
+	return "unknown panic"
+}
@@ -0,0 +1,147 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// JobType identifies the kind of action a job performs
+type JobType string
+
+const (
+	JobConnect  JobType = "connect"
+	JobMessage  JobType = "message"
+	JobWithdraw JobType = "withdraw"
+	JobEngage   JobType = "engage"
+)
+
+// Job is one unit of work on a browser page
+type Job struct {
+	Type       JobType
+	ProfileURL string
+	CampaignID string
+	// Reply marks a message to a prospect who accepted our request;
+	// these are answered before any first-touch action.
+	Reply      bool
+	EnqueuedAt time.Time
+	Run        func(ctx context.Context, page *rod.Page) error
+}
+
+// Base priorities per job type. Higher runs first.
+var basePriority = map[JobType]int{
+	JobMessage:  50,
+	JobWithdraw: 40,
+	JobConnect:  30,
+	JobEngage:   10,
+}
+
+// Priority tuning
+const (
+	// ReplyBoost is added for messages to accepted connections
+	ReplyBoost = 100
+	// AgeBoostPerHour is added for every hour a job has waited, up to MaxAgeBoost
+	AgeBoostPerHour = 2
+	MaxAgeBoost     = 40
+)
+
+// Priority returns the job's priority at now: type base, reply boost and aging
+func Priority(j Job, now time.Time) int {
+	p := basePriority[j.Type]
+	if j.Reply {
+		p += ReplyBoost
+	}
+	if !j.EnqueuedAt.IsZero() && now.After(j.EnqueuedAt) {
+		boost := int(now.Sub(j.EnqueuedAt)/time.Hour) * AgeBoostPerHour
+		if boost > MaxAgeBoost {
+			boost = MaxAgeBoost
+		}
+		p += boost
+	}
+	return p
+}
+
+// ---------------- HEAP ----------------
+
+type item struct {
+	job      Job
+	priority int
+	seq      int
+}
+
+type jobHeap []item
+
+func (h jobHeap) Len() int { return len(h) }
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	if !h[i].job.EnqueuedAt.Equal(h[j].job.EnqueuedAt) {
+		return h[i].job.EnqueuedAt.Before(h[j].job.EnqueuedAt)
+	}
+	return h[i].seq < h[j].seq
+}
+func (h jobHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *jobHeap) Push(x any)   { *h = append(*h, x.(item)) }
+func (h *jobHeap) Pop() any {
+	old := *h
+	it := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return it
+}
+
+// Queue orders jobs by priority. Priorities are computed when a job is
+// pushed, relative to the queue's reference time, so aging is stable for
+// the lifetime of one queue (one scheduler cycle).
+type Queue struct {
+	now  time.Time
+	h    jobHeap
+	next int
+}
+
+// New returns an empty queue that evaluates aging at now
+func New(now time.Time) *Queue {
+	return &Queue{now: now}
+}
+
+// Push adds jobs to the queue
+func (q *Queue) Push(jobs ...Job) {
+	for _, j := range jobs {
+		heap.Push(&q.h, item{job: j, priority: Priority(j, q.now), seq: q.next})
+		q.next++
+	}
+}
+
+// Len returns the number of queued jobs
+func (q *Queue) Len() int { return q.h.Len() }
+
+// Pop removes and returns the highest-priority job accepted by allow.
+// Jobs that are skipped stay queued in order.
+func (q *Queue) Pop(allow func(Job) bool) (Job, bool) {
+	skipped := []item{}
+	defer func() {
+		for _, it := range skipped {
+			heap.Push(&q.h, it)
+		}
+	}()
+
+	for q.h.Len() > 0 {
+		it := heap.Pop(&q.h).(item)
+		if allow == nil || allow(it.job) {
+			return it.job, true
+		}
+		skipped = append(skipped, it)
+	}
+	return Job{}, false
+}
+
+// Drain removes and returns all remaining jobs in priority order
+func (q *Queue) Drain() []Job {
+	jobs := make([]Job, 0, q.h.Len())
+	for q.h.Len() > 0 {
+		jobs = append(jobs, heap.Pop(&q.h).(item).job)
+	}
+	return jobs
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+)
+
+// LockTimeout bounds how long a quota operation waits for another process
+var LockTimeout = 10 * time.Second
+
+// staleLockAge is how old a lock file must be before it is treated as left
+// behind by a crashed process. Quota operations hold the lock for
+// milliseconds, so this is generous.
+const staleLockAge = 30 * time.Second
+
+// lockFile takes an exclusive, cross-process lock on path by creating
+// path+".lock" with O_EXCL. It returns the function that releases it.
+func lockFile(path string) (func(), error) {
+	lock := path + ".lock"
+	if err := ensureDir(lock); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			_ = f.Close()
+			return func() { _ = os.Remove(lock) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			// move it aside first so only one waiter clears a given stale lock
+			aside := fmt.Sprintf("%s.stale.%d", lock, os.Getpid())
+			if os.Rename(lock, aside) == nil {
+				_ = os.Remove(aside)
+			}
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("quota file %s is locked by another process (%s)", path, lock)
+		}
+		time.Sleep(20*time.Millisecond + time.Duration(rand.Intn(30))*time.Millisecond)
+	}
+}
@@ -2,25 +2,72 @@ package ratelimit
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
 )
 
-// Quotas stores counts per action for a given day
+// Quotas stores the recorded actions per action and scope. Global counts
+// are keyed by the action name, others by "action@scope".
 type Quotas map[string]ActionQuota
 
-// ActionQuota stores the date and count for an action
+// ActionQuota stores when an action ran and the reservations still open.
+// Date and Count are the legacy per-day counter and are converted to
+// Events on load.
 type ActionQuota struct {
-	Date  string `json:"date"`
-	Count int    `json:"count"`
+	Date     string        `json:"date,omitempty"`
+	Count    int           `json:"count,omitempty"`
+	Events   []time.Time   `json:"events,omitempty"`
+	Reserved []Reservation `json:"reserved,omitempty"`
+}
+
+// Retention is how long events are kept; it covers the longest window
+const Retention = 8 * 24 * time.Hour
+
+// ErrQuotaExceeded is returned when an action has used up its limit
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaError reports which limit was hit and when the next action is allowed.
+// errors.Is(err, ErrQuotaExceeded) matches it.
+type QuotaError struct {
+	Action  string
+	Scope   Scope
+	Limit   int
+	Period  Period
+	Rolling bool
+	ResetAt time.Time
+}
+
+func (e *QuotaError) Error() string {
+	kind := "fixed"
+	if e.Rolling {
+		kind = "rolling"
+	}
+	scope := e.Scope
+	if scope == "" {
+		scope = ScopeGlobal
+	}
+	return fmt.Sprintf("%s limit reached for %s (%d per %s, %s window), next allowed at %s",
+		scope, e.Action, e.Limit, e.Period, kind, e.ResetAt.Format(time.RFC3339))
+}
+
+// Shared reports whether the exhausted scope covers every action of its
+// kind (global or account), rather than one campaign or template
+func (e *QuotaError) Shared() bool {
+	return e.Scope == "" || e.Scope == ScopeGlobal || strings.HasPrefix(string(e.Scope), "account:")
 }
 
-// Default storage path
-var DefaultQuotaPath = "data/quotas.json"
+// Is makes QuotaError match ErrQuotaExceeded
+func (e *QuotaError) Is(target error) bool { return target == ErrQuotaExceeded }
 
+// mu serialises quota access within the process; lockFile does the same
+// across processes
 var mu sync.Mutex
 
 func ensureDir(path string) error {
@@ -32,9 +79,6 @@ func ensureDir(path string) error {
 }
 
 func loadQuotas(path string) (Quotas, error) {
-	if path == "" {
-		path = DefaultQuotaPath
-	}
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return Quotas{}, nil
 	}
@@ -46,13 +90,30 @@ func loadQuotas(path string) (Quotas, error) {
 	if err := json.Unmarshal(b, &q); err != nil {
 		return nil, err
 	}
+	if q == nil {
+		q = Quotas{}
+	}
+	for action, aq := range q {
+		q[action] = aq.migrate()
+	}
 	return q, nil
 }
 
-func saveQuotas(path string, q Quotas) error {
-	if path == "" {
-		path = DefaultQuotaPath
+// migrate turns a legacy day counter into events at the start of that day
+func (aq ActionQuota) migrate() ActionQuota {
+	if aq.Count > 0 && aq.Date != "" {
+		if day, err := time.ParseInLocation("2006-01-02", aq.Date, time.Local); err == nil {
+			for i := 0; i < aq.Count; i++ {
+				aq.Events = append(aq.Events, day)
+			}
+		}
 	}
+	aq.Date, aq.Count = "", 0
+	return aq
+}
+
+// saveQuotas writes q through a temp file so readers never see a partial file
+func saveQuotas(path string, q Quotas) error {
 	if err := ensureDir(path); err != nil {
 		return err
 	}
@@ -60,119 +121,213 @@ func saveQuotas(path string, q Quotas) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, b, 0o644)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
 }
 
-/*
-========================
-NEW: Check (no increment)
-========================
-*/
-
-// Check verifies quota without incrementing
-func Check(action string, limit int, path string) error {
-	if os.Getenv("DEV_IGNORE_QUOTAS") == "1" {
-		return nil
-	}
-	if limit <= 0 {
-		return nil
+// withQuotas runs fn on the quota file while holding both locks, saving
+// the result when save is true
+func withQuotas(path string, save bool, fn func(q Quotas, now time.Time) error) error {
+	if path == "" {
+		path = datadir.Path("quotas.json")
 	}
 
 	mu.Lock()
 	defer mu.Unlock()
 
-	q, err := loadQuotas(path)
+	unlock, err := lockFile(path)
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	today := time.Now().Format("2006-01-02")
-	aq, ok := q[action]
-	if !ok || aq.Date != today {
-		return nil // zero usage today
+	q, err := loadQuotas(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(q, time.Now()); err != nil {
+		return err
+	}
+	if !save {
+		return nil
 	}
+	return saveQuotas(path, q)
+}
 
-	if aq.Count >= limit {
-		return fmt.Errorf("daily limit reached for %s (%d)", action, limit)
+// prune drops events older than Retention and expired reservations
+func (aq ActionQuota) prune(now time.Time) ActionQuota {
+	cutoff := now.Add(-Retention)
+	kept := aq.Events[:0]
+	for _, e := range aq.Events {
+		if e.After(cutoff) {
+			kept = append(kept, e)
+		}
 	}
+	aq.Events = kept
 
-	return nil
+	held := aq.Reserved[:0]
+	for _, r := range aq.Reserved {
+		if r.ExpiresAt.After(now) {
+			held = append(held, r)
+		}
+	}
+	aq.Reserved = held
+	return aq
 }
 
-/*
-========================
-NEW: Increment only
-========================
-*/
+// counted returns the events plus the times of unexpired reservations,
+// which count against limits until they are committed or released
+func (aq ActionQuota) counted(now time.Time) []time.Time {
+	out := append([]time.Time{}, aq.Events...)
+	for _, r := range aq.Reserved {
+		if r.ExpiresAt.After(now) {
+			out = append(out, r.At)
+		}
+	}
+	return out
+}
 
-// Increment increments quota after success
-func Increment(action string, path string) error {
-	if os.Getenv("DEV_IGNORE_QUOTAS") == "1" {
+// check returns a QuotaError for the first scope in chain whose limits
+// would be exceeded by one more action, and the latest time any of them
+// allows it
+func check(q Quotas, action string, chain []ScopeLimits, now time.Time) error {
+	var qe *QuotaError
+	for _, sl := range chain {
+		next, l, err := NextAllowedAt(q[key(action, sl.Scope)].counted(now), sl.Limits, now)
+		if err != nil {
+			return err
+		}
+		if l == nil {
+			continue
+		}
+		if qe == nil {
+			qe = &QuotaError{Action: action, Scope: sl.Scope, Limit: l.Max, Period: l.Period, Rolling: l.Rolling, ResetAt: next}
+		} else if next.After(qe.ResetAt) {
+			qe.ResetAt = next
+		}
+	}
+	if qe == nil {
 		return nil
 	}
+	return qe
+}
 
-	mu.Lock()
-	defer mu.Unlock()
-
-	q, err := loadQuotas(path)
-	if err != nil {
-		return err
+// scopes returns chain's scopes, defaulting to the global scope
+func scopes(chain []ScopeLimits) []Scope {
+	if len(chain) == 0 {
+		return []Scope{ScopeGlobal}
 	}
-	if q == nil {
-		q = Quotas{}
+	out := make([]Scope, len(chain))
+	for i, sl := range chain {
+		out[i] = sl.Scope
 	}
+	return out
+}
 
-	today := time.Now().Format("2006-01-02")
-	aq, ok := q[action]
-	if !ok || aq.Date != today {
-		aq = ActionQuota{Date: today, Count: 0}
+// record stores one action in every scope
+func record(q Quotas, action string, in []Scope, now time.Time) {
+	for _, s := range in {
+		k := key(action, s)
+		aq := q[k]
+		aq.Events = append(aq.Events, now)
+		q[k] = aq.prune(now)
 	}
-
-	aq.Count++
-	q[action] = aq
-
-	return saveQuotas(path, q)
 }
 
 /*
 ========================
-EXISTING: CheckAndIncrement
-(unchanged behavior)
+Scoped limits
 ========================
 */
 
-// CheckAndIncrement checks whether `action` is under the daily `limit` and increments the counter if allowed.
-func CheckAndIncrement(action string, limit int, path string) error {
-	if os.Getenv("DEV_IGNORE_QUOTAS") == "1" {
+// Allow verifies that action may run now under every scope of chain,
+// without recording it
+func Allow(action string, chain []ScopeLimits, path string) error {
+	return withQuotas(path, false, func(q Quotas, now time.Time) error {
+		return check(q, action, chain, now)
+	})
+}
+
+// Record stores one completed action in every scope of chain
+func Record(action string, chain []ScopeLimits, path string) error {
+	return withQuotas(path, true, func(q Quotas, now time.Time) error {
+		record(q, action, scopes(chain), now)
 		return nil
-	}
-	if limit <= 0 {
+	})
+}
+
+// Take checks every scope of chain and, only if all allow it, records the
+// action in all of them in the same write
+func Take(action string, chain []ScopeLimits, path string) error {
+	return withQuotas(path, true, func(q Quotas, now time.Time) error {
+		if err := check(q, action, chain, now); err != nil {
+			return err
+		}
+		record(q, action, scopes(chain), now)
 		return nil
-	}
+	})
+}
 
-	mu.Lock()
-	defer mu.Unlock()
+// NextAllowed returns the earliest time at or after now when action may
+// run again under every scope of chain
+func NextAllowed(action string, chain []ScopeLimits, path string, now time.Time) (time.Time, error) {
+	next := now
+	err := withQuotas(path, false, func(q Quotas, _ time.Time) error {
+		for _, sl := range chain {
+			t, _, err := NextAllowedAt(q[key(action, sl.Scope)].counted(now), sl.Limits, now)
+			if err != nil {
+				return err
+			}
+			if t.After(next) {
+				next = t
+			}
+		}
+		return nil
+	})
+	return next, err
+}
 
-	q, err := loadQuotas(path)
-	if err != nil {
+// Used returns how many completed actions in scope count towards l at now
+func Used(action string, scope Scope, l Limit, path string, now time.Time) (int, error) {
+	used := 0
+	err := withQuotas(path, false, func(q Quotas, _ time.Time) error {
+		in, err := l.inWindow(q[key(action, scope)].Events, now)
+		used = len(in)
 		return err
-	}
-	if q == nil {
-		q = Quotas{}
-	}
+	})
+	return used, err
+}
 
-	today := time.Now().Format("2006-01-02")
-	aq, ok := q[action]
-	if !ok || aq.Date != today {
-		aq = ActionQuota{Date: today, Count: 0}
-	}
+/*
+========================
+Daily helpers (global scope)
+========================
+*/
 
-	if aq.Count >= limit {
-		return fmt.Errorf("daily limit reached for %s (%d)", action, limit)
+func globalDaily(limit int) []ScopeLimits {
+	return []ScopeLimits{{Scope: ScopeGlobal, Limits: []Limit{Daily(limit)}}}
+}
+
+// Check verifies the global calendar-day quota without incrementing
+func Check(action string, limit int, path string) error {
+	if limit <= 0 {
+		return nil
 	}
+	return Allow(action, globalDaily(limit), path)
+}
 
-	aq.Count++
-	q[action] = aq
+// Increment records one action in the global scope after success
+func Increment(action string, path string) error {
+	return Record(action, nil, path)
+}
 
-	return saveQuotas(path, q)
+// CheckAndIncrement checks whether `action` is under the global daily `limit` and increments the counter if allowed.
+func CheckAndIncrement(action string, limit int, path string) error {
+	if limit <= 0 {
+		return nil
+	}
+	return Take(action, globalDaily(limit), path)
 }
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// ReservationTTL is how long an uncommitted reservation holds its slot.
+// It only matters if the process dies between Reserve and Commit/Release.
+var ReservationTTL = 10 * time.Minute
+
+// Reservation is a quota slot held by an action in progress
+type Reservation struct {
+	ID        string    `json:"id"`
+	At        time.Time `json:"at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Token is a reservation returned by Reserve. Exactly one of Commit or
+// Release takes effect; later calls are no-ops, so
+//
+//	tok, err := ratelimit.Reserve(...)
+//	if err != nil { ... }
+//	defer tok.Release()
+//	... do the action ...
+//	tok.Commit()
+//
+// counts the action only if it completed.
+type Token struct {
+	action string
+	scopes []Scope
+	path   string
+	id     string
+
+	once sync.Once
+	err  error
+}
+
+// Reserve checks every scope of chain and holds one slot in each of them
+// until the token is committed or released. Concurrent reservations, also
+// from other processes, count against the limits, so they cannot overshoot.
+func Reserve(action string, chain []ScopeLimits, path string) (*Token, error) {
+	tok := &Token{action: action, scopes: scopes(chain), path: path, id: newReservationID()}
+	err := withQuotas(path, true, func(q Quotas, now time.Time) error {
+		if err := check(q, action, chain, now); err != nil {
+			return err
+		}
+		r := Reservation{ID: tok.id, At: now, ExpiresAt: now.Add(ReservationTTL)}
+		for _, s := range tok.scopes {
+			k := key(action, s)
+			aq := q[k].prune(now)
+			aq.Reserved = append(aq.Reserved, r)
+			q[k] = aq
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tok, nil
+}
+
+// Commit turns the reservation into a recorded action
+func (t *Token) Commit() error {
+	t.once.Do(func() {
+		t.err = withQuotas(t.path, true, func(q Quotas, now time.Time) error {
+			t.drop(q)
+			record(q, t.action, t.scopes, now)
+			return nil
+		})
+	})
+	return t.err
+}
+
+// Release gives the slot back without recording an action
+func (t *Token) Release() error {
+	if t == nil {
+		return nil
+	}
+	t.once.Do(func() {
+		t.err = withQuotas(t.path, true, func(q Quotas, now time.Time) error {
+			t.drop(q)
+			return nil
+		})
+	})
+	return t.err
+}
+
+// drop removes the token's reservation from every scope
+func (t *Token) drop(q Quotas) {
+	for _, s := range t.scopes {
+		k := key(t.action, s)
+		aq, ok := q[k]
+		if !ok {
+			continue
+		}
+		kept := aq.Reserved[:0]
+		for _, r := range aq.Reserved {
+			if r.ID != t.id {
+				kept = append(kept, r)
+			}
+		}
+		aq.Reserved = kept
+		q[k] = aq
+	}
+}
+
+func newReservationID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Scope is one level of the quota hierarchy:
+// global → account → campaign → template
+type Scope string
+
+// ScopeGlobal counts every action of the process
+const ScopeGlobal Scope = "global"
+
+// AccountScope returns the scope of one logged-in account ("" if id is empty)
+func AccountScope(id string) Scope { return prefixed("account:", id) }
+
+// CampaignScope returns the scope of one campaign ("" if id is empty)
+func CampaignScope(id string) Scope { return prefixed("campaign:", id) }
+
+// TemplateScope returns the scope of one message template ("" if id is empty)
+func TemplateScope(id string) Scope { return prefixed("template:", id) }
+
+func prefixed(prefix, id string) Scope {
+	if id == "" {
+		return ""
+	}
+	return Scope(prefix + id)
+}
+
+// ScopeLimits are the limits one scope applies to an action
+type ScopeLimits struct {
+	Scope  Scope
+	Limits []Limit
+}
+
+// key returns the storage key of action in scope; global counts keep the
+// bare action name so existing quota files stay valid
+func key(action string, scope Scope) string {
+	if scope == "" || scope == ScopeGlobal {
+		return action
+	}
+	return action + "@" + string(scope)
+}
+
+// Config holds the global and per-account limits, per action
+type Config struct {
+	Global   map[string][]Limit            `json:"global"`
+	Accounts map[string]map[string][]Limit `json:"accounts,omitempty"`
+}
+
+// DefaultConfigPath is where LoadConfig looks when no path is given
+var DefaultConfigPath = "data/limits.json"
+
+// DefaultConfig is used when the config file does not exist
+var DefaultConfig = Config{
+	Global: map[string][]Limit{
+		"message": {Daily(5)},
+	},
+}
+
+// LoadConfig reads the global and account limits
+func LoadConfig(path string) (Config, error) {
+	if path == "" {
+		path = DefaultConfigPath
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DefaultConfig, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// Chain returns the scopes checked for action, from global down: the
+// configured global and account limits followed by more (campaign,
+// template). Entries without a scope are skipped.
+func (c Config) Chain(action, account string, more ...ScopeLimits) []ScopeLimits {
+	chain := []ScopeLimits{{Scope: ScopeGlobal, Limits: c.Global[action]}}
+	if s := AccountScope(account); s != "" {
+		chain = append(chain, ScopeLimits{Scope: s, Limits: c.Accounts[account][action]})
+	}
+	for _, sl := range more {
+		if sl.Scope != "" {
+			chain = append(chain, sl)
+		}
+	}
+	return chain
+}
@@ -0,0 +1,211 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+)
+
+// Usage is one limit of one scope as it stands at a point in time. Limit
+// is nil for a scope that has recorded actions but no configured limit.
+type Usage struct {
+	Action   string
+	Scope    Scope
+	Limit    *Limit
+	Used     int
+	Reserved int
+	// WindowStart and ResetAt bound the current window; for a rolling
+	// limit ResetAt is when the oldest counted action leaves it, and zero
+	// if nothing is counted
+	WindowStart time.Time
+	ResetAt     time.Time
+}
+
+// Remaining returns how many more actions the limit allows now
+func (u Usage) Remaining() int {
+	if u.Limit == nil {
+		return 0
+	}
+	if n := u.Limit.Max - u.Used - u.Reserved; n > 0 {
+		return n
+	}
+	return 0
+}
+
+// splitKey is the inverse of key
+func splitKey(k string) (string, Scope) {
+	if i := strings.Index(k, "@"); i >= 0 {
+		return k[:i], Scope(k[i+1:])
+	}
+	return k, ScopeGlobal
+}
+
+// Status reports the usage of every limit in chains (keyed by action),
+// followed by any recorded action and scope without a configured limit
+func Status(chains map[string][]ScopeLimits, path string, now time.Time) ([]Usage, error) {
+	var out []Usage
+	err := withQuotas(path, false, func(q Quotas, _ time.Time) error {
+		seen := map[string]bool{}
+		for action, chain := range chains {
+			for _, sl := range chain {
+				k := key(action, sl.Scope)
+				aq := q[k].prune(now)
+				for i := range sl.Limits {
+					l := sl.Limits[i]
+					u, err := usage(action, sl.Scope, l, aq, now)
+					if err != nil {
+						return err
+					}
+					out = append(out, u)
+					seen[k] = true
+				}
+			}
+		}
+		for k, aq := range q {
+			if seen[k] {
+				continue
+			}
+			aq = aq.prune(now)
+			if len(aq.Events) == 0 && len(aq.Reserved) == 0 {
+				continue
+			}
+			action, scope := splitKey(k)
+			out = append(out, Usage{Action: action, Scope: scope, Used: len(aq.Events), Reserved: len(aq.Reserved)})
+		}
+		return nil
+	})
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Action != out[j].Action {
+			return out[i].Action < out[j].Action
+		}
+		return scopeRank(out[i].Scope) < scopeRank(out[j].Scope) ||
+			scopeRank(out[i].Scope) == scopeRank(out[j].Scope) && out[i].Scope < out[j].Scope
+	})
+	return out, err
+}
+
+// usage counts aq's events and reservations in l's window
+func usage(action string, scope Scope, l Limit, aq ActionQuota, now time.Time) (Usage, error) {
+	start, reset, err := l.Window(now)
+	if err != nil {
+		return Usage{}, err
+	}
+	in, err := l.inWindow(aq.Events, now)
+	if err != nil {
+		return Usage{}, err
+	}
+	reserved := reservedTimes(aq, start)
+	if l.Rolling {
+		// the window frees a slot when its oldest action ages out
+		reset = time.Time{}
+		if counted := append(append([]time.Time{}, in...), reserved...); len(counted) > 0 {
+			sort.Slice(counted, func(i, j int) bool { return counted[i].Before(counted[j]) })
+			reset = counted[0].Add(now.Sub(start))
+		}
+	}
+	return Usage{
+		Action:      action,
+		Scope:       scope,
+		Limit:       &l,
+		Used:        len(in),
+		Reserved:    len(reserved),
+		WindowStart: start,
+		ResetAt:     reset,
+	}, nil
+}
+
+// reservedTimes returns when aq's open reservations were taken, from since on
+func reservedTimes(aq ActionQuota, since time.Time) []time.Time {
+	var out []time.Time
+	for _, r := range aq.Reserved {
+		if !r.At.Before(since) {
+			out = append(out, r.At)
+		}
+	}
+	return out
+}
+
+// scopeRank orders scopes from global down to template
+func scopeRank(s Scope) int {
+	switch {
+	case s == "" || s == ScopeGlobal:
+		return 0
+	case strings.HasPrefix(string(s), "account:"):
+		return 1
+	case strings.HasPrefix(string(s), "campaign:"):
+		return 2
+	}
+	return 3
+}
+
+/*
+========================
+Reset
+========================
+*/
+
+// ResetAudit is one line of the quota audit log
+type ResetAudit struct {
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+	Scope    Scope     `json:"scope"`
+	Removed  int       `json:"removed"`
+	Released int       `json:"released"`
+	By       string    `json:"by,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// AuditPath returns the quota audit log file
+func AuditPath() string {
+	return datadir.Path("quota_audit.jsonl")
+}
+
+// Reset clears the recorded actions and open reservations of action in
+// scope (global if empty) and appends the change to the audit log. by and
+// reason are stored with the audit entry.
+func Reset(action string, scope Scope, by, reason, path string) (ResetAudit, error) {
+	if action == "" {
+		return ResetAudit{}, fmt.Errorf("quota reset: action is required")
+	}
+	if scope == "" {
+		scope = ScopeGlobal
+	}
+	entry := ResetAudit{Action: action, Scope: scope, By: by, Reason: reason}
+	err := withQuotas(path, true, func(q Quotas, now time.Time) error {
+		k := key(action, scope)
+		aq := q[k].prune(now)
+		entry.Time = now
+		entry.Removed = len(aq.Events)
+		entry.Released = len(aq.Reserved)
+		delete(q, k)
+		return nil
+	})
+	if err != nil {
+		return entry, err
+	}
+	return entry, appendAudit(entry)
+}
+
+func appendAudit(e ResetAudit) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	path := AuditPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Period is the length of a limit's window
+type Period string
+
+const (
+	PeriodHour Period = "hour"
+	PeriodDay  Period = "day"
+	PeriodWeek Period = "week"
+)
+
+// Limit caps an action to Max per Period.
+//
+// A fixed window starts on the hour, at midnight or on Monday at midnight
+// in Timezone (default: local time) and resets when the next one begins.
+// A rolling window counts the actions of the last hour, 24h or 7 days.
+type Limit struct {
+	Max      int    `json:"max"`
+	Period   Period `json:"period"`
+	Rolling  bool   `json:"rolling,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// Daily returns a fixed calendar-day limit in local time
+func Daily(max int) Limit {
+	return Limit{Max: max, Period: PeriodDay}
+}
+
+func (l Limit) String() string {
+	s := fmt.Sprintf("%d per %s", l.Max, l.Period)
+	if l.Rolling {
+		s += " (rolling)"
+	}
+	if l.Timezone != "" {
+		s += " " + l.Timezone
+	}
+	return s
+}
+
+func (p Period) duration() (time.Duration, error) {
+	switch p {
+	case PeriodHour:
+		return time.Hour, nil
+	case PeriodDay:
+		return 24 * time.Hour, nil
+	case PeriodWeek:
+		return 7 * 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("unknown rate limit period %q", p)
+}
+
+func (l Limit) location() (*time.Location, error) {
+	if l.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(l.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("rate limit timezone %q: %w", l.Timezone, err)
+	}
+	return loc, nil
+}
+
+// Window returns the window containing now. For a fixed window reset is
+// when the next window starts; for a rolling one it is now, since the
+// window moves with the clock.
+func (l Limit) Window(now time.Time) (start, reset time.Time, err error) {
+	d, err := l.Period.duration()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if l.Rolling {
+		return now.Add(-d), now, nil
+	}
+
+	loc, err := l.location()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	t := now.In(loc)
+	switch l.Period {
+	case PeriodHour:
+		start = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		reset = start.Add(time.Hour)
+	case PeriodDay:
+		start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		reset = start.AddDate(0, 0, 1)
+	case PeriodWeek:
+		back := (int(t.Weekday()) + 6) % 7 // days since Monday
+		start = time.Date(t.Year(), t.Month(), t.Day()-back, 0, 0, 0, 0, loc)
+		reset = start.AddDate(0, 0, 7)
+	}
+	return start, reset, nil
+}
+
+// inWindow returns the events that count towards l at now, oldest first
+func (l Limit) inWindow(events []time.Time, now time.Time) ([]time.Time, error) {
+	start, _, err := l.Window(now)
+	if err != nil {
+		return nil, err
+	}
+	out := []time.Time{}
+	for _, e := range events {
+		if e.After(now) {
+			continue
+		}
+		// a rolling window excludes the event that just aged out
+		if e.After(start) || (!l.Rolling && e.Equal(start)) {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out, nil
+}
+
+// nextAllowed returns the earliest time at or after now when one more
+// event fits under l
+func (l Limit) nextAllowed(events []time.Time, now time.Time) (time.Time, error) {
+	if l.Max <= 0 {
+		return now, nil
+	}
+	in, err := l.inWindow(events, now)
+	if err != nil {
+		return now, err
+	}
+	if len(in) < l.Max {
+		return now, nil
+	}
+	if !l.Rolling {
+		_, reset, err := l.Window(now)
+		return reset, err
+	}
+	// enough of the oldest events must age out to leave room for one more
+	d, _ := l.Period.duration()
+	return in[len(in)-l.Max].Add(d), nil
+}
+
+// NextAllowedAt returns the earliest time at or after now when action may
+// run once more under every limit, given its recorded events
+func NextAllowedAt(events []time.Time, limits []Limit, now time.Time) (time.Time, *Limit, error) {
+	next := now
+	var blocking *Limit
+	for i := range limits {
+		t, err := limits[i].nextAllowed(events, now)
+		if err != nil {
+			return now, nil, err
+		}
+		if t.After(next) {
+			next, blocking = t, &limits[i]
+		}
+	}
+	return next, blocking, nil
+}
@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRollingWindowEviction(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	l := Limit{Max: 2, Period: PeriodHour, Rolling: true}
+	events := []time.Time{now.Add(-time.Hour), now.Add(-30 * time.Minute)}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		wantIn   int
+		wantNext time.Time
+	}{
+		// the event exactly one period old has aged out
+		{"at period", now, 1, now},
+		{"just before period", now.Add(-time.Nanosecond), 2, now},
+		{"after both age out", now.Add(30 * time.Minute), 0, now.Add(30 * time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := l.inWindow(events, tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(in) != tt.wantIn {
+				t.Errorf("inWindow at %s = %d events, want %d", tt.at.Format(time.RFC3339Nano), len(in), tt.wantIn)
+			}
+			next, err := l.nextAllowed(events, tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !next.Equal(tt.wantNext) {
+				t.Errorf("nextAllowed at %s = %s, want %s", tt.at.Format(time.RFC3339Nano), next, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestRollingNextAllowedWaitsForOldest(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	l := Limit{Max: 2, Period: PeriodDay, Rolling: true}
+	events := []time.Time{now.Add(-20 * time.Hour), now.Add(-2 * time.Hour), now.Add(-time.Hour)}
+
+	next, err := l.nextAllowed(events, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// two of the three must age out; the 2h-old one does so 22h from now
+	if want := now.Add(22 * time.Hour); !next.Equal(want) {
+		t.Errorf("nextAllowed = %s, want %s", next, want)
+	}
+}
+
+func TestFixedDayResetsAtLocalMidnight(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	l := Limit{Max: 1, Period: PeriodDay, Timezone: "America/New_York"}
+	// 23:30 in New York is already the next day in UTC
+	now := time.Date(2026, time.March, 10, 23, 30, 0, 0, loc)
+	midnight := time.Date(2026, time.March, 11, 0, 0, 0, 0, loc)
+
+	start, reset, err := l.Window(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, time.March, 10, 0, 0, 0, 0, loc); !start.Equal(want) {
+		t.Errorf("window start = %s, want %s", start, want)
+	}
+	if !reset.Equal(midnight) {
+		t.Errorf("window reset = %s, want %s", reset, midnight)
+	}
+
+	tests := []struct {
+		name     string
+		events   []time.Time
+		at       time.Time
+		wantNext time.Time
+	}{
+		{"used up before midnight", []time.Time{now.Add(-time.Hour)}, now, midnight},
+		{"counted from local midnight", []time.Time{start}, now, midnight},
+		{"previous local day not counted", []time.Time{start.Add(-time.Minute)}, now, now},
+		{"fresh after midnight", []time.Time{now.Add(-time.Hour)}, midnight, midnight},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := l.nextAllowed(tt.events, tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !next.Equal(tt.wantNext) {
+				t.Errorf("nextAllowed at %s = %s, want %s", tt.at, next, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestFixedWeekStartsMonday(t *testing.T) {
+	l := Limit{Max: 1, Period: PeriodWeek, Timezone: "UTC"}
+	// 2026-03-15 is a Sunday
+	start, reset, err := l.Window(time.Date(2026, time.March, 15, 18, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, time.March, 9, 0, 0, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("week start = %s, want %s", start, want)
+	}
+	if want := time.Date(2026, time.March, 16, 0, 0, 0, 0, time.UTC); !reset.Equal(want) {
+		t.Errorf("week reset = %s, want %s", reset, want)
+	}
+}
+
+func TestLegacyCounterMigration(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 15, 0, 0, 0, time.Local)
+	path := filepath.Join(t.TempDir(), "quotas.json")
+	legacy := `{
+  "connect": {"date": "2026-03-10", "count": 3},
+  "message": {"date": "2026-03-09", "count": 4}
+}`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := loadQuotas(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aq := q["connect"]; aq.Date != "" || aq.Count != 0 || len(aq.Events) != 3 {
+		t.Errorf("migrated connect quota = %+v, want 3 events and no legacy fields", aq)
+	}
+
+	tests := []struct {
+		action string
+		want   int
+	}{
+		{"connect", 3},
+		// yesterday's counter no longer counts towards today's window
+		{"message", 0},
+	}
+	for _, tt := range tests {
+		used, err := Used(tt.action, ScopeGlobal, Daily(10), path, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if used != tt.want {
+			t.Errorf("Used(%s) after migration = %d, want %d", tt.action, used, tt.want)
+		}
+	}
+
+	// the migrated counter still blocks once the daily limit is reached
+	next, err := NextAllowed("connect", globalDaily(3), path, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, time.March, 11, 0, 0, 0, 0, time.Local); !next.Equal(want) {
+		t.Errorf("NextAllowed after migration = %s, want %s", next, want)
+	}
+}
@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
+)
+
+// Cycle is one daemon wake-up. It should leave all queues saved when it returns.
+type Cycle func(ctx context.Context) error
+
+// RunDaemon runs cycle immediately and then at every wake time of sched
+// until ctx is cancelled. A running cycle sees the cancellation through
+// ctx and is expected to stop its browser work and save its queues before
+// returning. lg receives the daemon log lines (slog.Default() if nil).
+func RunDaemon(ctx context.Context, sched Schedule, cycle Cycle, lg *slog.Logger) error {
+	lg = logging.Or(lg).With(logging.KeyAction, "daemon")
+	for {
+		started := time.Now()
+		lg.Info("daemon cycle started")
+		if err := cycle(ctx); err != nil {
+			lg.Warn("daemon cycle failed", "error", err)
+		} else {
+			lg.Info("daemon cycle finished", "duration", time.Since(started).Round(time.Second))
+		}
+
+		if ctx.Err() != nil {
+			lg.Info("daemon stopping")
+			return nil
+		}
+
+		next := sched.Next(time.Now())
+		lg.Info("next daemon cycle", "at", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			lg.Info("daemon stopping")
+			return nil
+		case <-timer.C:
+		}
+	}
+}
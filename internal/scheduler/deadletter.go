@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/connect"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+)
+
+// DeadLetter is a pending message that exhausted its retry attempts
+type DeadLetter struct {
+	Message  connect.PendingMessage `json:"message"`
+	FailedAt time.Time              `json:"failed_at"`
+}
+
+// LoadDeadLetters reads the dead-letter list
+func LoadDeadLetters(path string) ([]DeadLetter, error) {
+	if path == "" {
+		path = datadir.Path("dead_letters.json")
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return []DeadLetter{}, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var arr []DeadLetter
+	if err := json.Unmarshal(b, &arr); err != nil {
+		return nil, err
+	}
+	return arr, nil
+}
+
+func saveDeadLetters(path string, arr []DeadLetter) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(arr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+func appendDeadLetters(path string, add []DeadLetter) error {
+	arr, err := LoadDeadLetters(path)
+	if err != nil {
+		return err
+	}
+	return saveDeadLetters(path, append(arr, add...))
+}
+
+// Requeue moves dead letters for profileURL (or all of them, if profileURL
+// is empty) back onto the pending queue with a fresh retry budget.
+// It returns the number of messages requeued.
+func Requeue(cfg SchedulerConfig, profileURL string) (int, error) {
+	cfg.applyDefaults()
+
+	dead, err := LoadDeadLetters(cfg.DeadLetterPath)
+	if err != nil {
+		return 0, err
+	}
+
+	kept := make([]DeadLetter, 0, len(dead))
+	requeue := []connect.PendingMessage{}
+	for _, d := range dead {
+		if profileURL != "" && d.Message.ProfileURL != profileURL {
+			kept = append(kept, d)
+			continue
+		}
+		pm := d.Message
+		pm.Attempts = 0
+		pm.LastError = ""
+		pm.NextAttemptAt = nil
+		requeue = append(requeue, pm)
+	}
+	if len(requeue) == 0 {
+		return 0, nil
+	}
+
+	err = connect.UpdatePending(cfg.PendingPath, func(pend []connect.PendingMessage) []connect.PendingMessage {
+		return append(pend, requeue...)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(requeue), saveDeadLetters(cfg.DeadLetterPath, kept)
+}
@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/campaign"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/connect"
+)
+
+// PlannedMessage is a pending message with the time the scheduler expects to send it
+type PlannedMessage struct {
+	Message   connect.PendingMessage
+	PlannedAt time.Time
+	// WindowErr is set when the campaign's send window could not be evaluated
+	WindowErr error
+}
+
+// PlannedSendTime returns the earliest time pm may be sent: its next
+// attempt time (or now), moved forward into the campaign's send window.
+// The prospect's timezone is read from the "timezone" var.
+func PlannedSendTime(pm connect.PendingMessage, camp *campaign.Campaign, now time.Time) (time.Time, error) {
+	t := now
+	if pm.NextAttemptAt != nil && pm.NextAttemptAt.After(now) {
+		t = *pm.NextAttemptAt
+	}
+	if camp == nil || camp.SendWindow == nil {
+		return t, nil
+	}
+	return camp.SendWindow.NextOpen(t, pm.Vars["timezone"])
+}
+
+// Plan returns the pending queue ordered by planned send time
+func Plan(cfg SchedulerConfig, now time.Time) ([]PlannedMessage, error) {
+	cfg.applyDefaults()
+
+	pend, err := connect.LoadPending(cfg.PendingPath)
+	if err != nil {
+		return nil, err
+	}
+	camps, _ := campaign.LoadCampaigns(cfg.CampaignsPath)
+
+	plan := make([]PlannedMessage, 0, len(pend))
+	for _, pm := range pend {
+		at, err := PlannedSendTime(pm, campaign.GetCampaignByID(camps, pm.CampaignID), now)
+		plan = append(plan, PlannedMessage{Message: pm, PlannedAt: at, WindowErr: err})
+	}
+	sort.SliceStable(plan, func(i, j int) bool {
+		return plan[i].PlannedAt.Before(plan[j].PlannedAt)
+	})
+	return plan, nil
+}
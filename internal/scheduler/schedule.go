@@ -0,0 +1,166 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when the daemon wakes next
+type Schedule interface {
+	// Next returns the first wake time strictly after t
+	Next(t time.Time) time.Time
+}
+
+// Every wakes at a fixed interval
+type Every time.Duration
+
+func (e Every) Next(t time.Time) time.Time {
+	d := time.Duration(e)
+	if d <= 0 {
+		d = time.Minute
+	}
+	return t.Add(d)
+}
+
+// CronSchedule is a standard 5-field cron expression
+// (minute hour day-of-month month day-of-week) evaluated in local time.
+type CronSchedule struct {
+	expr   string
+	minute [60]bool
+	hour   [24]bool
+	dom    [32]bool
+	month  [13]bool
+	dow    [7]bool
+	// cron semantics: if both day fields are restricted, either may match
+	domAny bool
+	dowAny bool
+}
+
+// ParseCron parses a 5-field cron expression. Each field supports
+// "*", single values, lists ("1,15"), ranges ("9-17") and steps ("*/15",
+// "0-30/5", "5/15" = every 15 from 5).
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	c := &CronSchedule{expr: expr}
+	if err := parseCronField(fields[0], 0, 59, c.minute[:]); err != nil {
+		return nil, fmt.Errorf("cron %q minute: %w", expr, err)
+	}
+	if err := parseCronField(fields[1], 0, 23, c.hour[:]); err != nil {
+		return nil, fmt.Errorf("cron %q hour: %w", expr, err)
+	}
+	if err := parseCronField(fields[2], 1, 31, c.dom[:]); err != nil {
+		return nil, fmt.Errorf("cron %q day-of-month: %w", expr, err)
+	}
+	if err := parseCronField(fields[3], 1, 12, c.month[:]); err != nil {
+		return nil, fmt.Errorf("cron %q month: %w", expr, err)
+	}
+	// accept 7 as Sunday
+	dow := make([]bool, 8)
+	if err := parseCronField(fields[4], 0, 7, dow); err != nil {
+		return nil, fmt.Errorf("cron %q day-of-week: %w", expr, err)
+	}
+	copy(c.dow[:], dow[:7])
+	c.dow[0] = c.dow[0] || dow[7]
+
+	// like Vixie cron, a day field starting with "*" (including "*/2")
+	// counts as unrestricted for the either-day rule
+	c.domAny = strings.HasPrefix(fields[2], "*")
+	c.dowAny = strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+func parseCronField(field string, min, max int, set []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		step, stepped := 1, false
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("bad step in %q", part)
+			}
+			step, stepped = n, true
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			a, err1 := strconv.Atoi(bounds[0])
+			b, err2 := strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return fmt.Errorf("bad range %q", part)
+			}
+			lo, hi = a, b
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return fmt.Errorf("bad value %q", part)
+			}
+			lo, hi = n, n
+			if stepped {
+				// "5/15" runs from 5 to the end of the range: 5, 20, 35, 50
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+func (c *CronSchedule) String() string { return c.expr }
+
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom[t.Day()]
+	dow := c.dow[int(t.Weekday())]
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// Next returns the first matching minute strictly after t.
+// It gives up after five years, which only happens for impossible dates like "0 0 30 2 *".
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !c.month[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.hour[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.minute[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return limit
+}
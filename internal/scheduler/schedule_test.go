@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCronFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		min   int
+		max   int
+		want  []int
+	}{
+		{"star", "*", 0, 5, []int{0, 1, 2, 3, 4, 5}},
+		{"star step", "*/15", 0, 59, []int{0, 15, 30, 45}},
+		{"range step", "10-30/10", 0, 59, []int{10, 20, 30}},
+		{"value step", "5/15", 0, 59, []int{5, 20, 35, 50}},
+		{"list", "1,15,30", 0, 59, []int{1, 15, 30}},
+		{"list of steps", "1-3,*/20", 0, 59, []int{0, 1, 2, 3, 20, 40}},
+		{"range", "9-12", 0, 23, []int{9, 10, 11, 12}},
+		{"single", "7", 0, 23, []int{7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := make([]bool, tt.max+1)
+			if err := parseCronField(tt.field, tt.min, tt.max, set); err != nil {
+				t.Fatalf("parseCronField(%q): %v", tt.field, err)
+			}
+			var got []int
+			for v, ok := range set {
+				if ok {
+					got = append(got, v)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCronField(%q) = %v, want %v", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestCronDayMatches(t *testing.T) {
+	// 2026-03-01 is a Sunday, 2026-03-02 a Monday, 2026-03-15 a Sunday
+	tests := []struct {
+		name string
+		expr string
+		day  int
+		want bool
+	}{
+		{"both star", "0 0 * * *", 3, true},
+		{"dom only", "0 0 15 * *", 15, true},
+		{"dom only miss", "0 0 15 * *", 2, false},
+		{"dow only", "0 0 * * 1", 2, true},
+		{"dow only miss", "0 0 * * 1", 3, false},
+		{"both restricted, dom", "0 0 15 * 1", 15, true},
+		{"both restricted, dow", "0 0 15 * 1", 2, true},
+		{"both restricted, neither", "0 0 15 * 1", 3, false},
+		{"starred dom step is unrestricted", "0 0 */2 * 1", 3, false},
+		{"starred dom step, dow", "0 0 */2 * 1", 2, true},
+		{"starred dow step is unrestricted", "0 0 15 * */2", 1, false},
+		{"starred dow step, dom", "0 0 15 * */2", 15, true},
+		{"sunday as 7", "0 0 * * 7", 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			day := time.Date(2026, time.March, tt.day, 0, 0, 0, 0, time.UTC)
+			if got := c.dayMatches(day); got != tt.want {
+				t.Errorf("%q on %s = %v, want %v", tt.expr, day.Format("Mon 2006-01-02"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(y int, mo time.Month, d, h, mi int) time.Time {
+		return time.Date(y, mo, d, h, mi, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"strictly after", "*/15 * * * *", at(2026, 3, 2, 10, 15), at(2026, 3, 2, 10, 30)},
+		{"seconds are dropped", "*/15 * * * *", at(2026, 3, 2, 10, 14).Add(30 * time.Second), at(2026, 3, 2, 10, 15)},
+		{"value step", "5/15 * * * *", at(2026, 3, 2, 10, 36), at(2026, 3, 2, 10, 50)},
+		{"next hour", "0 9-17 * * *", at(2026, 3, 2, 17, 0), at(2026, 3, 3, 9, 0)},
+		{"month boundary", "30 8 1 * *", at(2026, 1, 15, 0, 0), at(2026, 2, 1, 8, 30)},
+		{"short month", "0 0 31 * *", at(2026, 4, 1, 0, 0), at(2026, 5, 31, 0, 0)},
+		{"year boundary", "0 0 1 1 *", at(2026, 12, 31, 23, 59), at(2027, 1, 1, 0, 0)},
+		{"weekday across year", "0 9 * * 1-5", at(2026, 12, 31, 10, 0), at(2027, 1, 1, 9, 0)},
+		{"leap day", "0 0 29 2 *", at(2026, 3, 1, 0, 0), at(2028, 2, 29, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			if got := c.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("%q.Next(%s) = %s, want %s", tt.expr, tt.from.Format(time.RFC3339), got.Format(time.RFC3339), tt.want.Format(time.RFC3339))
+			}
+		})
+	}
+}
@@ -1,81 +1,576 @@
 package scheduler
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/go-rod/rod"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/approval"
 	"github.com/sushmitaRN/linkedin-automation-poc/internal/behavior"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/campaign"
 	"github.com/sushmitaRN/linkedin-automation-poc/internal/connect"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/datadir"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dnc"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
 	"github.com/sushmitaRN/linkedin-automation-poc/internal/message"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/prospect"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/queue"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/ratelimit"
 	"github.com/sushmitaRN/linkedin-automation-poc/internal/templates"
 )
 
 // Note: pending messages are stored in data/pending_messages.json
 // This scheduler will attempt to send pending messages using the message module.
 
+// Retry defaults
+const (
+	DefaultMaxAttempts = 5
+	DefaultBaseBackoff = 15 * time.Minute
+	DefaultMaxBackoff  = 24 * time.Hour
+)
+
 type SchedulerConfig struct {
 	PendingPath   string
 	TemplatesPath string
+	CampaignsPath string
 	MsgStorage    string
+	// SentRequestsPath is read to prioritise replies to accepted connections
+	SentRequestsPath string
+	DeadLetterPath   string
+	// Account selects the account-scope quota limits
+	Account string
+	// DryRun types messages without sending them and leaves the pending
+	// queue and dead letters unchanged
+	DryRun bool
+
+	// MaxAttempts failed sends move a message to the dead-letter list.
+	// Waiting for a connection to be accepted does not count as an attempt.
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	// Logger receives the scheduler and message log lines (slog.Default() if nil)
+	Logger *slog.Logger
 }
 
-// ProcessPending loads pending messages and attempts to send them.
-// Successfully sent messages are removed from the pending queue.
-func ProcessPending(page *rod.Page, cfg SchedulerConfig) error {
+func (cfg *SchedulerConfig) applyDefaults() {
 	if cfg.PendingPath == "" {
-		cfg.PendingPath = "data/pending_messages.json"
-	}
-	if cfg.TemplatesPath == "" {
-		cfg.TemplatesPath = ""
+		cfg.PendingPath = datadir.Path("pending_messages.json")
 	}
 	if cfg.MsgStorage == "" {
-		cfg.MsgStorage = "data/sent_messages.json"
+		cfg.MsgStorage = datadir.Path("sent_messages.json")
+	}
+	if cfg.SentRequestsPath == "" {
+		cfg.SentRequestsPath = datadir.Path("sent_requests.json")
+	}
+	if cfg.DeadLetterPath == "" {
+		cfg.DeadLetterPath = datadir.Path("dead_letters.json")
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = DefaultBaseBackoff
 	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultMaxBackoff
+	}
+}
+
+// Backoff returns the delay before the next attempt after `attempts` failures:
+// base, 2×base, 4×base, … capped at max.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// MessageBatch tracks the pending messages handled in one scheduler cycle.
+// Its jobs record their results in the batch; Save merges them back into
+// the pending file so entries enqueued or removed meanwhile are kept.
+type MessageBatch struct {
+	cfg SchedulerConfig
 
-	// Load templates
+	mu      sync.Mutex
+	updated map[string]connect.PendingMessage
+	removed map[string]bool
+	// replied holds profiles that wrote back; all their messages are dropped
+	replied map[string]bool
+	// added holds the next sequence steps queued by successful sends
+	added []connect.PendingMessage
+	dead  []DeadLetter
+	// due holds the IDs of messages with a job in this batch
+	due map[string]bool
+	// approvals maps approved messages to their approval item
+	approvals map[string]string
+	// quotaReset is set once a send hits a global or account message
+	// quota; due messages that did not run are deferred to it on Save
+	quotaReset *time.Time
+}
+
+// PrepareMessages loads the pending queue and returns a job for every due
+// message. Messages outside their send window are deferred in the batch
+// instead. Messages to accepted connections are flagged as replies so they
+// are sent before first-touch actions.
+func PrepareMessages(cfg SchedulerConfig, now time.Time) (*MessageBatch, []queue.Job, error) {
+	cfg.applyDefaults()
+
+	// Load templates and campaigns (for send windows)
 	tpls, _ := templates.LoadTemplates(cfg.TemplatesPath)
+	camps, _ := campaign.LoadCampaigns(cfg.CampaignsPath)
+	lg := logging.Or(cfg.Logger)
+	states, err := campaign.LoadStates("")
+	if err != nil {
+		lg.Warn("could not load campaign states", "error", err)
+	}
+	accepted, _ := connect.AcceptedProfiles(cfg.SentRequestsPath)
+	prospects, _ := prospect.Load("")
+	byURL := map[string]prospect.Prospect{}
+	for _, p := range prospects {
+		byURL[p.ProfileURL] = p
+	}
 
 	// Load pending messages
 	pend, err := connect.LoadPending(cfg.PendingPath)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	remaining := make([]connect.PendingMessage, 0, len(pend))
+	b := &MessageBatch{
+		cfg:       cfg,
+		updated:   map[string]connect.PendingMessage{},
+		removed:   map[string]bool{},
+		replied:   map[string]bool{},
+		due:       map[string]bool{},
+		approvals: map[string]string{},
+	}
+	jobs := []queue.Job{}
 
 	for _, pm := range pend {
-		// find template body
-		var body string
-		for _, t := range tpls {
-			if t.ID == pm.TemplateID {
-				body = t.Body
-				break
-			}
+		if !pm.Due(now) {
+			continue
+		}
+
+		// paused campaigns keep their messages queued as they are
+		if states[pm.CampaignID].Paused {
+			continue
 		}
-		if body == "" {
-			log.Printf("template %s not found, skipping message to %s", pm.TemplateID, pm.ProfileURL)
-			remaining = append(remaining, pm)
+
+		camp := campaign.GetCampaignByID(camps, pm.CampaignID)
+		plg := b.logger(pm)
+
+		// evaluate the step's condition against what is known of the prospect
+		if reason, ok := stepAllowed(pm, camp, byURL[pm.ProfileURL]); !ok {
+			plg.Info("dropping follow-up", "reason", reason)
+			b.removed[pm.ID] = true
 			continue
 		}
 
-		// attempt to send
-		err := message.SendMessageIfConnected(page, pm.ProfileURL, body, pm.Vars, message.MessageConfig{StoragePath: cfg.MsgStorage})
+		// drop follow-ups to prospects on the do-not-contact list
+		target := dnc.Target{ProfileURL: pm.ProfileURL, Company: pm.Vars["company"]}
+		if err := dnc.Guard("message", target, cfg.DryRun, plg); err != nil {
+			if errors.Is(err, dnc.ErrBlocked) {
+				b.removed[pm.ID] = true
+			}
+			continue
+		}
+
+		// defer messages that fall outside the campaign's send window
+		planned, err := PlannedSendTime(pm, camp, now)
 		if err != nil {
-			log.Printf("pending message not sent to %s: %v", pm.ProfileURL, err)
-			remaining = append(remaining, pm)
-		} else {
-			log.Printf("pending message sent to %s", pm.ProfileURL)
+			plg.Warn("could not compute send window", "error", err)
+		} else if planned.After(now) {
+			pm.NextAttemptAt = &planned
+			plg.Info("outside send window, deferred", "until", planned.Format(time.RFC3339))
+			b.updated[pm.ID] = pm
+			continue
 		}
 
-		// wait a bit between messages
-		behavior.SleepHuman(800*time.Millisecond, 1500*time.Millisecond)
+		msgCfg := message.MessageConfig{
+			StoragePath: cfg.MsgStorage,
+			Account:     cfg.Account,
+			CampaignID:  pm.CampaignID,
+			TemplateID:  pm.TemplateID,
+			DryRun:      cfg.DryRun,
+			// message adds the action, prospect and campaign itself
+			Logger: lg.With(logging.KeyStep, pm.Step, "template", pm.TemplateID),
+		}
+		if camp != nil {
+			msgCfg.Limits = camp.Limits["message"]
+		}
+		body := ""
+		if t := templates.GetTemplateByID(tpls, pm.TemplateID); t != nil {
+			body = t.Body
+			msgCfg.TemplateDailyLimit = t.DailyLimit
+		}
+
+		// in approval mode only the reviewed text is sent
+		if camp != nil && camp.RequireApproval && body != "" {
+			it, ok := b.review(pm, body, now)
+			if !ok {
+				continue
+			}
+			body = it.Text
+			msgCfg.ApprovedBy = it.ReviewedBy
+			b.approvals[pm.ID] = it.ID
+		}
+
+		// defer messages while any of their quota scopes is used up
+		if next, err := nextMessageAllowed(msgCfg, now); err != nil {
+			plg.Warn("could not check message quota", "error", err)
+		} else if next.After(now) {
+			pm.NextAttemptAt = &next
+			plg.Info("message quota reached, deferred", "until", next.Format(time.RFC3339))
+			b.updated[pm.ID] = pm
+			continue
+		}
+
+		pm := pm
+		b.due[pm.ID] = true
+		jobs = append(jobs, queue.Job{
+			Type:       queue.JobMessage,
+			ProfileURL: pm.ProfileURL,
+			CampaignID: pm.CampaignID,
+			Reply:      accepted[pm.ProfileURL],
+			EnqueuedAt: pm.CreatedAt,
+			Run: func(ctx context.Context, page *rod.Page) error {
+				return b.send(ctx, page, pm, camp, body, msgCfg)
+			},
+		})
 	}
 
-	// Save remaining pending messages
-	if err := connect.SavePending(cfg.PendingPath, remaining); err != nil {
-		log.Printf("warning: could not save pending messages: %v", err)
+	return b, jobs, nil
+}
+
+// logger returns the batch logger with pm's campaign, prospect, step and
+// template attached
+func (b *MessageBatch) logger(pm connect.PendingMessage) *slog.Logger {
+	return logging.Or(b.cfg.Logger).With(
+		logging.KeyAction, "message",
+		logging.KeyProspect, pm.ProfileURL,
+		logging.KeyCampaign, pm.CampaignID,
+		logging.KeyStep, pm.Step,
+		"template", pm.TemplateID,
+	)
+}
+
+// review looks pm up in the approval queue and returns the approved item.
+// A message seen for the first time is rendered and queued for review;
+// rejected messages are dropped. ok is false until the item is approved.
+func (b *MessageBatch) review(pm connect.PendingMessage, body string, now time.Time) (approval.Item, bool) {
+	lg := b.logger(pm)
+	it, found, err := approval.Find("", approval.KindMessage, pm.ProfileURL, pm.ID)
+	if err != nil {
+		lg.Warn("could not read approval queue", "error", err)
+		return it, false
+	}
+	if found {
+		switch it.Status {
+		case approval.StatusApproved:
+			return it, true
+		case approval.StatusRejected:
+			lg.Info("message rejected", "by", it.ReviewedBy, "reason", it.Reason)
+			b.removed[pm.ID] = true
+		}
+		return it, false
+	}
+
+	policy, err := message.LoadPolicy("")
+	if err != nil {
+		lg.Warn("could not load content policy", "error", err)
+		return it, false
+	}
+	text, err := policy.Render(body, pm.Vars)
+	if err != nil {
+		// reviewers should not see text the policy refuses anyway
+		pm.Attempts++
+		pm.LastError = err.Error()
+		lg.Warn("message breaks the content policy, moving to dead letters", "error", err)
+		b.dead = append(b.dead, DeadLetter{Message: pm, FailedAt: now})
+		b.removed[pm.ID] = true
+		return it, false
 	}
+	if b.cfg.DryRun {
+		lg.Info("dry run: would queue message for approval")
+		return it, false
+	}
+	it, err = approval.Submit("", approval.Item{
+		Kind:       approval.KindMessage,
+		Key:        pm.ID,
+		ProfileURL: pm.ProfileURL,
+		CampaignID: pm.CampaignID,
+		TemplateID: pm.TemplateID,
+		Text:       text,
+	})
+	if err != nil {
+		lg.Warn("could not queue message for approval", "error", err)
+		return it, false
+	}
+	lg.Info("message queued for approval", "approval", it.ID)
+	return it, false
+}
 
+// sequenceStep returns pm's step in camp's sequence
+func sequenceStep(pm connect.PendingMessage, camp *campaign.Campaign) (int, campaign.Step, bool) {
+	if camp == nil {
+		return 0, campaign.Step{}, false
+	}
+	idx := pm.Step
+	if idx == 0 {
+		var ok bool
+		if idx, ok = camp.StepIndex(pm.TemplateID); !ok {
+			return 0, campaign.Step{}, false
+		}
+	}
+	steps := camp.Steps()
+	if idx >= len(steps) {
+		return 0, campaign.Step{}, false
+	}
+	return idx, steps[idx], true
+}
+
+// stepAllowed evaluates pm's step condition against the prospect record.
+// A reply ends every sequence; acceptance is checked on the profile when
+// the message is sent.
+func stepAllowed(pm connect.PendingMessage, camp *campaign.Campaign, p prospect.Prospect) (string, bool) {
+	if p.Status == prospect.StatusReplied {
+		return "prospect replied", false
+	}
+	if p.Status == prospect.StatusSkipped {
+		return "prospect skipped: " + p.SkipReason, false
+	}
+	idx, step, ok := sequenceStep(pm, camp)
+	if !ok {
+		return "", true
+	}
+	if step.Condition == campaign.ConditionNoReply && p.RepliedAt != nil {
+		return "prospect replied", false
+	}
+	if p.CampaignID == pm.CampaignID && p.Step >= idx && p.Status != prospect.StatusInvited && p.Status != prospect.StatusNew {
+		return fmt.Sprintf("step %d already done", idx+1), false
+	}
+	return "", true
+}
+
+// advance queues the sequence step after pm, delayed from now, and records
+// the prospect's progress. Messages outside any sequence end here.
+func (b *MessageBatch) advance(pm connect.PendingMessage, camp *campaign.Campaign, now time.Time) {
+	idx, _, ok := sequenceStep(pm, camp)
+	if !ok || b.cfg.DryRun {
+		return
+	}
+
+	status := prospect.StatusCompleted
+	if next, step, ok := camp.NextMessage(idx); ok {
+		at := now.Add(time.Duration(step.Delay))
+		b.added = append(b.added, connect.PendingMessage{
+			ProfileURL:    pm.ProfileURL,
+			CampaignID:    pm.CampaignID,
+			TemplateID:    step.TemplateID,
+			Vars:          pm.Vars,
+			Step:          next,
+			CreatedAt:     now,
+			NextAttemptAt: &at,
+		})
+		status = prospect.StatusInSequence
+		b.logger(pm).Info("next step scheduled", "next_step", next, "next_template", step.TemplateID, "at", at.Format(time.RFC3339))
+	}
+
+	if err := prospect.Update("", pm.ProfileURL, func(p *prospect.Prospect) {
+		p.CampaignID, p.Step, p.Status, p.LastContactAt = pm.CampaignID, idx, status, &now
+	}); err != nil {
+		b.logger(pm).Warn("could not update prospect", "error", err)
+	}
+}
+
+// nextMessageAllowed returns when msgCfg's quota scopes next allow a message
+func nextMessageAllowed(msgCfg message.MessageConfig, now time.Time) (time.Time, error) {
+	chain, err := msgCfg.QuotaChain()
+	if err != nil {
+		return now, err
+	}
+	return ratelimit.NextAllowed("message", chain, datadir.Path("quotas.json"), now)
+}
+
+// send attempts one pending message and records the result in the batch
+func (b *MessageBatch) send(ctx context.Context, page *rod.Page, pm connect.PendingMessage, camp *campaign.Campaign, body string, msgCfg message.MessageConfig) error {
+	now := time.Now()
+	lg := b.logger(pm)
+
+	var sendErr error
+	if body == "" {
+		sendErr = fmt.Errorf("template %s not found", pm.TemplateID)
+	} else {
+		sendErr = message.SendMessageIfConnected(ctx, page, pm.ProfileURL, body, pm.Vars, msgCfg)
+	}
+
+	var quotaErr *ratelimit.QuotaError
+	var repliedErr *message.RepliedError
+	b.mu.Lock()
+	switch {
+	case sendErr != nil && errors.Is(ctx.Err(), context.Canceled):
+		// interrupted by shutdown: leave the message as it was so the next
+		// cycle retries it without spending an attempt. A job timeout still
+		// counts as a failed attempt.
+		lg.Info("pending message interrupted", "error", sendErr)
+
+	case sendErr == nil:
+		lg.Info("pending message sent")
+		b.removed[pm.ID] = true
+		if id := b.approvals[pm.ID]; id != "" && !b.cfg.DryRun {
+			if err := approval.MarkSent("", id); err != nil {
+				lg.Warn("could not mark approved message sent", "approval", id, "error", err)
+			}
+		}
+		b.advance(pm, camp, now)
+
+	case errors.As(sendErr, &quotaErr):
+		// the daily quota is used up: wait for the reset without spending an attempt
+		pm.NextAttemptAt = &quotaErr.ResetAt
+		lg.Info("message quota reached, deferred", "until", quotaErr.ResetAt.Format(time.RFC3339))
+		b.updated[pm.ID] = pm
+		if quotaErr.Shared() {
+			b.quotaReset = &quotaErr.ResetAt
+		}
+
+	case errors.As(sendErr, &repliedErr):
+		// the prospect wrote back: stop the sequence
+		lg.Info("prospect replied, cancelling pending follow-ups")
+		b.replied[pm.ProfileURL] = true
+		if !b.cfg.DryRun {
+			if err := prospect.MarkReplied("", pm.ProfileURL, repliedErr.Reply.SentAt); err != nil {
+				lg.Warn("could not mark prospect replied", "error", err)
+			}
+		}
+		sendErr = nil
+
+	case errors.Is(sendErr, dnc.ErrBlocked):
+		// opted out since the message was queued: drop it for good
+		lg.Info("pending message cancelled", "error", sendErr)
+		b.removed[pm.ID] = true
+
+	case errors.Is(sendErr, message.ErrPolicy):
+		// retrying renders the same text: dead-letter it for a fix right away
+		pm.Attempts++
+		pm.LastError = sendErr.Error()
+		pm.NextAttemptAt = nil
+		lg.Warn("message breaks the content policy, moving to dead letters", "error", sendErr)
+		b.dead = append(b.dead, DeadLetter{Message: pm, FailedAt: now})
+		b.removed[pm.ID] = true
+
+	case errors.Is(sendErr, message.ErrNotConnected):
+		// not a failure: check again later without spending an attempt
+		next := now.Add(b.cfg.BaseBackoff)
+		pm.NextAttemptAt = &next
+		lg.Info("connection not accepted yet", "next_check", next.Format(time.RFC3339))
+		b.updated[pm.ID] = pm
+		sendErr = nil
+
+	default:
+		pm.Attempts++
+		pm.LastError = sendErr.Error()
+		if pm.Attempts >= b.cfg.MaxAttempts {
+			lg.Error("pending message failed, moving to dead letters", "attempts", pm.Attempts, "error", sendErr)
+			pm.NextAttemptAt = nil
+			b.dead = append(b.dead, DeadLetter{Message: pm, FailedAt: now})
+			b.removed[pm.ID] = true
+			break
+		}
+		next := now.Add(Backoff(pm.Attempts, b.cfg.BaseBackoff, b.cfg.MaxBackoff))
+		pm.NextAttemptAt = &next
+		lg.Warn("pending message not sent",
+			"attempt", pm.Attempts, "max_attempts", b.cfg.MaxAttempts, "retry_at", next.Format(time.RFC3339), "error", sendErr)
+		b.updated[pm.ID] = pm
+	}
+	b.mu.Unlock()
+
+	// wait a bit between messages
+	behavior.SleepHuman(800*time.Millisecond, 1500*time.Millisecond)
+	return sendErr
+}
+
+// Save merges the batch results into the pending file and appends dead letters
+func (b *MessageBatch) Save() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.cfg.DryRun {
+		return nil
+	}
+
+	err := connect.UpdatePending(b.cfg.PendingPath, func(pend []connect.PendingMessage) []connect.PendingMessage {
+		out := make([]connect.PendingMessage, 0, len(pend))
+		for _, pm := range pend {
+			if b.removed[pm.ID] || b.replied[pm.ProfileURL] {
+				continue
+			}
+			if u, ok := b.updated[pm.ID]; ok {
+				pm = u
+			} else if b.quotaReset != nil && b.due[pm.ID] {
+				pm.NextAttemptAt = b.quotaReset
+			}
+			out = append(out, pm)
+		}
+		return out
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, pm := range b.added {
+		if _, err := connect.EnqueuePending(b.cfg.PendingPath, pm); err != nil {
+			return err
+		}
+	}
+	b.added = nil
+
+	if len(b.dead) > 0 {
+		if err := appendDeadLetters(b.cfg.DeadLetterPath, b.dead); err != nil {
+			return err
+		}
+		b.dead = nil
+	}
+	return nil
+}
+
+// HaltOnQuota is a queue.Dispatcher HaltType that stops a job type once it
+// has hit a global or account quota, instead of trying and failing each
+// remaining item. Campaign and template quotas only block their own items.
+func HaltOnQuota(err error) bool {
+	var qe *ratelimit.QuotaError
+	return errors.As(err, &qe) && qe.Shared()
+}
+
+// ProcessPending loads pending messages and attempts to send the ones that are due,
+// replies to accepted connections first.
+// Successfully sent messages are removed from the pending queue; failures are
+// retried with exponential backoff and dead-lettered after MaxAttempts.
+func ProcessPending(ctx context.Context, page *rod.Page, cfg SchedulerConfig) error {
+	now := time.Now()
+	batch, jobs, err := PrepareMessages(cfg, now)
+	if err != nil {
+		return err
+	}
+
+	q := queue.New(now)
+	q.Push(jobs...)
+	d := queue.Dispatcher{Page: page, HaltType: HaltOnQuota, Logger: cfg.Logger}
+	d.Run(ctx, q)
+
+	if err := batch.Save(); err != nil {
+		logging.Or(cfg.Logger).Warn("could not save pending messages", "error", err)
+	}
 	return nil
 }
@@ -1,13 +1,18 @@
 package search
 
 import (
+	"context"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/proto"
 	"github.com/sushmitaRN/linkedin-automation-poc/internal/behavior"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/dom"
+	"github.com/sushmitaRN/linkedin-automation-poc/internal/logging"
 )
 
 // SearchConfig holds configuration for search operations
@@ -15,6 +20,10 @@ type SearchConfig struct {
 	SearchInputID  string
 	SearchButtonID string
 	ProfileLinkSel string
+	// Timeout bounds waiting for results when ctx has no deadline (default 15s)
+	Timeout time.Duration
+	// Logger receives the search log lines (slog.Default() if nil)
+	Logger *slog.Logger
 }
 
 // DefaultSearchConfig returns sensible defaults for the mock search page
@@ -23,40 +32,66 @@ func DefaultSearchConfig() SearchConfig {
 		SearchInputID:  "#search-input",
 		SearchButtonID: "#search-btn",
 		ProfileLinkSel: ".profile-card a",
+		Timeout:        15 * time.Second,
 	}
 }
 
 // Search performs search on CURRENT page and returns profile links
-func Search(page *rod.Page, query string, cfg SearchConfig) ([]*rod.Element, error) {
-	log.Printf("Searching for %q", query)
+func Search(ctx context.Context, page *rod.Page, query string, cfg SearchConfig) ([]*rod.Element, error) {
+	page = page.Context(ctx)
+
+	lg := logging.Or(cfg.Logger).With(logging.KeyAction, "search", "query", query)
+	lg.Info("searching")
 
 	// Ensure search input is visible
-	input := page.MustElement(cfg.SearchInputID)
-	input.MustWaitVisible()
+	input, err := dom.Find(page, cfg.SearchInputID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("search input: %w", err)
+	}
+	if err := input.WaitVisible(); err != nil {
+		return nil, fmt.Errorf("search input: %w", err)
+	}
 
 	// Type query
-	input.MustSelectAllText()
+	if err := input.SelectAllText(); err != nil {
+		return nil, fmt.Errorf("select search text: %w", err)
+	}
 	if err := behavior.HumanType(input, query); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("type query: %w", err)
 	}
 
 	behavior.ThinkPause()
 
 	// Click search
-	btn := page.MustElement(cfg.SearchButtonID)
+	btn, err := dom.Find(page, cfg.SearchButtonID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("search button: %w", err)
+	}
 	if err := btn.Click(proto.InputMouseButtonLeft, 1); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("click search: %w", err)
 	}
 
-	// Wait for results
-	page.MustWaitElementsMoreThan(cfg.ProfileLinkSel, 0)
+	// Wait for results. The timeout applies to the wait only: elements read
+	// from a page with a cancelled context fail every later call.
+	waitCtx := ctx
+	if _, ok := ctx.Deadline(); !ok && cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+	if err := page.Context(waitCtx).WaitElementsMoreThan(cfg.ProfileLinkSel, 0); err != nil {
+		return nil, fmt.Errorf("wait for results: %w", err)
+	}
 
-	results := page.MustElements(cfg.ProfileLinkSel)
+	results, err := page.Elements(cfg.ProfileLinkSel)
+	if err != nil {
+		return nil, fmt.Errorf("read results: %w", err)
+	}
 	if len(results) == 0 {
 		return nil, errors.New("no profiles found")
 	}
 
-	log.Printf("✓ Found %d profiles for %q", len(results), query)
+	lg.Info("found profiles", "count", len(results))
 	return results, nil
 }
 
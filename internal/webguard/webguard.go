@@ -0,0 +1,112 @@
+package webguard
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// cookieName holds the token once a browser has opened a ?token= link
+const cookieName = "token"
+
+// Guard protects the local web pages (dashboard, approval queue) from
+// other sites open in the same browser: requests must name the listen
+// address or a loopback host, and form posts must come from the page
+// itself. With a Token every request must also carry it.
+type Guard struct {
+	// Addr is the listen address; Host headers must match it or a
+	// loopback name with the same port
+	Addr string
+	// Token, if set, is required as "Authorization: Bearer <token>" or
+	// through the cookie set by opening any page with ?token=<token>
+	Token string
+}
+
+// Wrap returns next behind the guard
+func (g Guard) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !g.knownHost(r.Host) {
+			// a DNS rebinding page reaches us under its own host name
+			http.Error(w, "unexpected host", http.StatusForbidden)
+			return
+		}
+
+		bearer := false
+		if g.Token != "" {
+			if t := r.URL.Query().Get("token"); t != "" && r.Method == http.MethodGet {
+				if !g.tokenOK(t) {
+					http.Error(w, "invalid token", http.StatusUnauthorized)
+					return
+				}
+				http.SetCookie(w, &http.Cookie{Name: cookieName, Value: t, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+				http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+				return
+			}
+			if t, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && g.tokenOK(t) {
+				bearer = true
+			} else if c, err := r.Cookie(cookieName); err != nil || !g.tokenOK(c.Value) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="automation"`)
+				http.Error(w, "missing or invalid token (open the page with ?token=<token>)", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		// browsers cannot attach a bearer header cross-site, so only
+		// cookie and unauthenticated posts need an origin check
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !bearer && !SameOrigin(r) {
+			http.Error(w, "cross-site request refused", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (g Guard) tokenOK(t string) bool {
+	return subtle.ConstantTimeCompare([]byte(t), []byte(g.Token)) == 1
+}
+
+// knownHost reports whether host is the listen address or a loopback
+// name on its port. Without an Addr any port is accepted.
+func (g Guard) knownHost(host string) bool {
+	if host == "" {
+		return false
+	}
+	if g.Addr != "" && strings.EqualFold(host, g.Addr) {
+		return true
+	}
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		name, port = host, ""
+	}
+	if _, want, err := net.SplitHostPort(g.Addr); err == nil && port != want {
+		return false
+	}
+	return IsLoopback(name)
+}
+
+// IsLoopback reports whether host (without port) names the local machine
+func IsLoopback(host string) bool {
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// SameOrigin reports whether a state-changing request came from a page
+// served by the same host. Browsers send Sec-Fetch-Site or Origin with
+// every post; a request with neither is refused.
+func SameOrigin(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site == "same-origin"
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}